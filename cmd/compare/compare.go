@@ -1,33 +1,65 @@
 package compare
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
-
+	"strings"
+
+	"github.com/openshift-kni/reference-validator/pkg/cluster"
+	"github.com/openshift-kni/reference-validator/pkg/compare"
+	"github.com/openshift-kni/reference-validator/pkg/helm"
+	"github.com/openshift-kni/reference-validator/pkg/kustomize"
+	"github.com/openshift-kni/reference-validator/pkg/policy"
+	"github.com/openshift-kni/reference-validator/pkg/reference"
+	"github.com/openshift-kni/reference-validator/pkg/report"
+	"github.com/openshift-kni/reference-validator/pkg/resource"
 	"github.com/openshift-kni/reference-validator/pkg/util"
+	"github.com/openshift-kni/reference-validator/pkg/ztp"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
-	k8sdiff "k8s.io/kubectl/pkg/cmd/diff"
-	configurationPolicyv1 "open-cluster-management.io/config-policy-controller/api/v1"
-	policyv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
-
-	// to be used for objectmeta definition.
-	_ "sigs.k8s.io/cli-utils/pkg/object"
 )
 
+var errDifferencesFound = errors.New("resources do not match the reference")
+
+var errInvalidInput = errors.New("invalid input found in strict mode")
+
 type compareOptions struct {
-	ReferenceDirs  []string
-	ResourceDirs   []string
-	ExactMatchOnly bool
+	ReferenceDirs      []string
+	ReferenceKustomize []string
+	ResourceDirs       []string
+	ResourceKustomize  []string
+	ResourceHelm       []string
+	HelmValues         []string
+	ReleaseName        string
+	ReleaseNamespace   string
+	ResourceCluster    bool
+	ResourceFormat     string
+	ConfigFlags        *genericclioptions.ConfigFlags
+	SourceCRDirs       []string
+	TemplateValues     string
+	TemplateObjects    []string
+	TemplateWildcards  bool
+	ClusterLabels      map[string]string
+	IncludeDisabled    bool
+	Namespaces         []string
+	ExactMatchOnly     bool
+	Strict             bool
+	Output             string
+	Color              string
+	IgnoreFields       []string
+	IgnoreFile         string
+	NoDefaultIgnores   bool
+	Match              string
 }
 
 func NewCmdCompare() *cobra.Command {
-	options := &compareOptions{}
+	options := &compareOptions{ConfigFlags: genericclioptions.NewConfigFlags(true)}
+	// the namespaces of the live objects are the ones of the reference objects
+	options.ConfigFlags.Namespace = nil
 
 	cmd := &cobra.Command{
 		Use:   "compare",
@@ -40,62 +72,201 @@ func NewCmdCompare() *cobra.Command {
 
 				return err
 			}
-			options.run()
+			cmd.SilenceUsage = true
 
-			return nil
+			return options.run(cmd.Context(), cmd.OutOrStdout())
 		},
 	}
 
 	// flags
-	cmd.Flags().StringSliceVarP(&options.ReferenceDirs, "reference", "", []string{}, "Reference configuration directory such as source-cr directory from ZTP")
+	cmd.Flags().StringSliceVarP(&options.ReferenceDirs, "reference", "", []string{},
+		"Reference configuration directory such as source-cr directory from ZTP, a .tar.gz, .tar or .zip archive or a "+
+			"git:<repository>@<ref>:<subdir> source, optionally with a "+reference.MetadataFile+" describing its components, or - for the standard input")
+	cmd.Flags().StringSliceVarP(&options.ReferenceKustomize, "reference-kustomize", "", []string{},
+		"Kustomization directory whose rendered objects are part of the reference, built without network access")
+
+	cmd.Flags().StringSliceVarP(&options.ResourceDirs, "resource", "", []string{},
+		"User configuration directory, .tar.gz, .tar or .zip archive or git:<repository>@<ref>:<subdir> source to read from, or - for the standard input")
+	cmd.Flags().StringSliceVarP(&options.ResourceKustomize, "resource-kustomize", "", []string{},
+		"Kustomization directory whose rendered objects are part of the resources, built without network access")
+	cmd.Flags().StringSliceVarP(&options.ResourceHelm, "resource-helm", "", []string{},
+		"Local Helm chart directory whose rendered manifests are part of the resources, rendered offline")
+	cmd.Flags().StringSliceVarP(&options.HelmValues, "values", "", []string{},
+		"YAML file overriding the values of the --resource-helm charts, the last one taking precedence")
+	cmd.Flags().StringVarP(&options.ReleaseName, "release-name", "", "release-name", "Release name the --resource-helm charts are rendered for")
+	cmd.Flags().StringVarP(&options.ReleaseNamespace, "release-namespace", "", "default", "Release namespace the --resource-helm charts are rendered for")
+	cmd.Flags().StringVarP(&options.ResourceFormat, "resource-format", "", resource.FormatFiles,
+		fmt.Sprintf("Layout of the --resource inputs, one of: %s. The objects of a must-gather without a reference are not reported",
+			strings.Join(resource.Formats, ", ")))
+	cmd.Flags().BoolVarP(&options.ResourceCluster, "resource-cluster", "", false,
+		"Read the live object of every reference object from the cluster of the kubeconfig context instead of --resource")
+
+	cmd.Flags().StringSliceVarP(&options.SourceCRDirs, "source-crs", "", []string{},
+		"ZTP source-crs directory used to generate the CRs of PolicyGenTemplates, after the source-crs directory next to each PolicyGenTemplate")
+	cmd.Flags().StringVarP(&options.TemplateValues, "template-values", "", "",
+		"YAML file with the values the templates of Policies are rendered with, such as ManagedClusterName")
+	cmd.Flags().StringSliceVarP(&options.TemplateObjects, "template-objects", "", []string{},
+		"Directory of the ConfigMaps, Secrets and other objects that the templates of Policies look up on the hub and managed cluster")
+	cmd.Flags().BoolVarP(&options.TemplateWildcards, "template-wildcards", "", false,
+		"Match any value where a template of a Policy cannot be rendered instead of reporting it")
+	cmd.Flags().StringToStringVarP(&options.ClusterLabels, "cluster-labels", "", nil,
+		"Labels of the managed cluster, such as name=sno1,du-profile=4.14, so that only the Policies placed on it are compared")
+	cmd.Flags().BoolVarP(&options.IncludeDisabled, "include-disabled", "", false,
+		"Compare the CRs of disabled Policies too, which ACM does not propagate")
+	cmd.Flags().StringSliceVarP(&options.Namespaces, "namespaces", "", []string{},
+		"Namespaces the namespaceSelector of ConfigurationPolicies selects from by name, instead of the Namespaces of the resources")
+	cmd.Flags().BoolVarP(&options.ExactMatchOnly, "exact-match-only", "", false, "Return early by determining if both sets are exact match")
+	cmd.Flags().StringVarP(&options.Output, "output", "o", report.FormatText,
+		fmt.Sprintf("Output format of the results, one of: %s", strings.Join(report.Formats, ", ")))
+	cmd.Flags().StringVarP(&options.Color, "color", "", report.ColorAuto,
+		fmt.Sprintf("Color the diff output, one of: %s", strings.Join(report.ColorModes, ", ")))
+	cmd.Flags().StringVarP(&options.Match, "match", "", string(compare.MatchSubset),
+		"How a resource must match its reference: subset allows fields absent from the reference, exact requires identical objects")
+	cmd.Flags().StringSliceVarP(&options.IgnoreFields, "ignore-field", "", []string{},
+		"JSON path (.metadata.labels.app) or JSONPointer (/metadata/labels/app) of a field to ignore on every object")
+	cmd.Flags().StringVarP(&options.IgnoreFile, "ignore-file", "", "", "YAML file listing global and per-kind fields to ignore")
+	cmd.Flags().BoolVarP(&options.NoDefaultIgnores, "no-default-ignores", "", false,
+		"Compare server-populated fields such as metadata.uid, metadata.managedFields and status")
+	cmd.Flags().BoolVarP(&options.Strict, "strict", "", false, "Fail on any file, document or Policy that cannot be read instead of skipping it")
+	options.ConfigFlags.AddFlags(cmd.Flags())
 
-	err := cmd.MarkFlagRequired("reference")
-	if err != nil {
-		return nil
+	return cmd
+}
+
+func (o compareOptions) validate() error {
+	if !isOneOf(o.Output, report.Formats) {
+		return fmt.Errorf("unsupported output format %q, must be one of: %s", o.Output, strings.Join(report.Formats, ", "))
 	}
 
-	cmd.Flags().StringSliceVarP(&options.ResourceDirs, "resource", "", []string{}, "User configuration directory to read from")
+	if !isOneOf(o.Match, compare.MatchModes) {
+		return fmt.Errorf("unsupported match mode %q, must be one of: %s", o.Match, strings.Join(compare.MatchModes, ", "))
+	}
 
-	err = cmd.MarkFlagRequired("resource")
-	if err != nil {
-		return nil
+	if !isOneOf(o.Color, report.ColorModes) {
+		return fmt.Errorf("unsupported color mode %q, must be one of: %s", o.Color, strings.Join(report.ColorModes, ", "))
 	}
 
-	cmd.Flags().BoolVarP(&options.ExactMatchOnly, "exact-match-only", "", false, "Return early by determining if both sets are exact match")
+	if !isOneOf(o.ResourceFormat, resource.Formats) {
+		return fmt.Errorf("unsupported resource format %q, must be one of: %s", o.ResourceFormat, strings.Join(resource.Formats, ", "))
+	}
 
-	return cmd
-}
+	if o.ResourceFormat == resource.FormatMustGather && countStdin(o.ResourceDirs) > 0 {
+		return errors.New("a must-gather cannot be read from the standard input")
+	}
+
+	if o.ResourceFormat == resource.FormatMustGather && len(o.ResourceKustomize)+len(o.ResourceHelm) > 0 {
+		return errors.New("--resource-kustomize and --resource-helm cannot be combined with a must-gather")
+	}
+
+	if len(o.ReferenceDirs)+len(o.ReferenceKustomize) == 0 {
+		return errors.New("one of --reference and --reference-kustomize is required")
+	}
+
+	if (len(o.ResourceDirs)+len(o.ResourceKustomize)+len(o.ResourceHelm) > 0) == o.ResourceCluster {
+		return errors.New("exactly one of --resource, --resource-kustomize, --resource-helm and --resource-cluster is required")
+	}
+
+	if countStdin(o.ReferenceDirs, o.ResourceDirs, o.TemplateObjects) > 1 {
+		return errors.New("the standard input can only be read once")
+	}
 
-func (o compareOptions) validate() error {
 	for _, dir := range o.ReferenceDirs {
-		if !util.IsDirectory(dir) {
-			return errors.New("all Reference paths must be a directory")
+		if !isInput(dir) {
+			return errors.New("all Reference paths must be a directory, an archive, a git source or -")
 		}
 	}
 
 	for _, dir := range o.ResourceDirs {
+		if !isInput(dir) {
+			return errors.New("all Resource paths must be a directory, an archive, a git source or -")
+		}
+	}
+
+	for _, dir := range append(append([]string{}, o.ReferenceKustomize...), o.ResourceKustomize...) {
 		if !util.IsDirectory(dir) {
-			return errors.New("all Resource paths must be a directory")
+			return errors.New("all kustomize paths must be a directory")
+		}
+	}
+
+	for _, dir := range o.ResourceHelm {
+		if !util.IsDirectory(dir) {
+			return errors.New("all resource-helm paths must be a directory")
+		}
+	}
+
+	for _, dir := range o.SourceCRDirs {
+		if !util.IsDirectory(dir) {
+			return errors.New("all source-crs paths must be a directory")
+		}
+	}
+
+	for _, dir := range o.TemplateObjects {
+		if !isInput(dir) {
+			return errors.New("all template-objects paths must be a directory, an archive, a git source or -")
+		}
+	}
+
+	for _, path := range append(append(append([]string{}, o.ReferenceDirs...), o.ResourceDirs...), o.TemplateObjects...) {
+		if !resource.IsGitSource(path) {
+			continue
+		}
+
+		if err := resource.CheckGitSource(path); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-func (o compareOptions) run() {
-	slog.Info("preparing resources")
+func (o compareOptions) run(ctx context.Context, out io.Writer) error {
+	policyOptions := policy.Options{
+		TemplateWildcards: o.TemplateWildcards, ClusterLabels: o.ClusterLabels, IncludeDisabled: o.IncludeDisabled,
+	}
+
+	if o.TemplateValues != "" {
+		values, err := policy.ReadTemplateValues(o.TemplateValues)
+		if err != nil {
+			return fmt.Errorf("invalid --template-values: %w", err)
+		}
 
-	var uListResources []unstructured.Unstructured
+		policyOptions.TemplateValues = values
+	}
 
-	uListResources = readK8sResourcesFromDir(o.ResourceDirs, uListResources)
-	uListResources = getResourceFromPolicyIfAny(uListResources)
+	templateObjects, diags := resource.ReadDirs(o.TemplateObjects)
+	policyOptions.TemplateObjects = templateObjects
 
 	slog.Info("preparing reference")
 
-	var uListReference []unstructured.Unstructured
+	metadata, err := reference.ReadMetadata(o.ReferenceDirs)
+	if err != nil {
+		return fmt.Errorf("could not read reference: %w", err)
+	}
 
-	uListReference = readK8sResourcesFromDir(o.ReferenceDirs, uListReference)
-	uListReference = getResourceFromPolicyIfAny(uListReference)
+	uListReference, refDiags := reference.ReadDirs(o.ReferenceDirs)
+	diags = append(diags, refDiags...)
+	kustomized, kustomizeDiags := kustomize.BuildDirs(o.ReferenceKustomize)
+	uListReference = append(uListReference, kustomized...)
+	diags = append(diags, kustomizeDiags...)
+	uListReference, expandDiags := o.expand(uListReference, policyOptions)
+	diags = append(diags, expandDiags...)
+
+	slog.Info("preparing resources")
+
+	uListReference, uListResources, resDiags, err := o.readResources(ctx, uListReference, policyOptions)
+	if err != nil {
+		return err
+	}
+
+	diags = append(diags, resDiags...)
+
+	if o.Strict && len(diags) > 0 {
+		resource.PrintDiagnostics(out, diags)
+
+		return errInvalidInput
+	}
+
+	resource.LogDiagnostics(diags)
 
 	// short circuit. Useful for ACM vs ZTP cases
 	eMatch := equalUnstructuredList(uListResources, uListReference)
@@ -110,119 +281,100 @@ func (o compareOptions) run() {
 		os.Exit(1)
 	}
 
-	_ = k8sdiff.DiffProgram{
-		Exec:      nil,
-		IOStreams: genericclioptions.IOStreams{},
-	}
-}
-
-func getResourceFromPolicyIfAny(uList []unstructured.Unstructured) []unstructured.Unstructured {
-	// Extract the main CR if policy
-	var uListWithoutP []unstructured.Unstructured
-
-	for _, curUnstructured := range uList {
-		if curUnstructured.GetKind() == "Policy" {
-			policy := policyv1.Policy{}
-
-			err := runtime.DefaultUnstructuredConverter.FromUnstructured(curUnstructured.Object, &policy)
-			if err != nil {
-				slog.Warn("invalid Policy CR")
-
-				continue
-			}
-
-			uListWithoutP = append(uListWithoutP, getObjectTemplates(policy)...)
-
-			continue
-		}
-
-		uListWithoutP = append(uListWithoutP, curUnstructured)
+	ignore, err := compare.NewIgnoreList(!o.NoDefaultIgnores, o.IgnoreFields, o.IgnoreFile)
+	if err != nil {
+		return fmt.Errorf("could not read ignored fields: %w", err)
 	}
 
-	return uListWithoutP
-}
+	// a must-gather holds the whole cluster, so its objects without a reference are not unexpected
+	comparator := compare.NewComparator(compare.Config{
+		Ignore: ignore, Match: compare.MatchMode(o.Match), IgnoreUnexpected: o.ResourceFormat == resource.FormatMustGather,
+	})
+	result := metadata.Evaluate(comparator.Compare(uListReference, uListResources))
 
-func readK8sResourcesFromDir(curDir []string, uList []unstructured.Unstructured) []unstructured.Unstructured {
-	for _, d := range curDir {
-		files, _ := util.GetFileNames(d)
-		for _, f := range files {
-			u := yamlToUnstructured(f)
-			if u != nil {
-				uList = append(uList, *u)
-			}
-		}
+	if err := report.Write(out, result, o.Output, report.UseColor(o.Color, out)); err != nil {
+		return fmt.Errorf("could not write report: %w", err)
 	}
 
-	return uList
-}
-
-func yamlToUnstructured(file string) *unstructured.Unstructured {
-	yFile, _ := os.ReadFile(file)
-	newUnstructured := &unstructured.Unstructured{Object: map[string]interface{}{}}
-
-	if err := yaml.Unmarshal(yFile, &newUnstructured.Object); err != nil {
-		slog.Warn(fmt.Sprintf("could not convert %s to Unstructured, skipping", file))
-
-		return nil
+	if !result.Passed() {
+		return errDifferencesFound
 	}
 
-	return newUnstructured
+	return nil
 }
 
-func getConfigurationPolicy(p policyv1.Policy) []configurationPolicyv1.ConfigurationPolicy {
-	var cPs []configurationPolicyv1.ConfigurationPolicy
-
-	for _, policyTemplate := range p.Spec.PolicyTemplates {
-		uConfigPolicy := &unstructured.Unstructured{}
-
-		err := uConfigPolicy.UnmarshalJSON(policyTemplate.ObjectDefinition.Raw)
+// readResources reads, builds, renders and expands the resource directories, kustomizations and
+// charts, reads the must-gathers, or fetches the live objects of the references from the cluster.
+// The namespace selectors of the references and resources are expanded against the Namespaces of
+// the resources, or --namespaces when set, and the references are returned expanded.
+func (o compareOptions) readResources(ctx context.Context, references []resource.Object, policyOptions policy.Options) ([]resource.Object, []resource.Object, []resource.Diagnostic, error) {
+	if o.ResourceCluster {
+		reader, err := cluster.NewReaderFromFlags(o.ConfigFlags)
 		if err != nil {
-			slog.Warn("could not unmarshal unstructured ConfigPolicy")
-
-			continue
+			return nil, nil, nil, fmt.Errorf("invalid --resource-cluster: %w", err)
 		}
 
-		tConfigPolicy := configurationPolicyv1.ConfigurationPolicy{}
-
-		err = runtime.DefaultUnstructuredConverter.FromUnstructured(uConfigPolicy.UnstructuredContent(), &tConfigPolicy)
-		if err != nil {
-			slog.Warn("could not convert unstructured ConfigPolicy to typed ConfigPolicy")
-
-			continue
+		var namespaces []resource.Object
+		if len(o.Namespaces) == 0 {
+			if namespaces, err = reader.Namespaces(ctx); err != nil {
+				return nil, nil, nil, fmt.Errorf("could not read the cluster: %w", err)
+			}
 		}
 
-		cPs = append(cPs, tConfigPolicy)
+		// the live objects are fetched after the references are expanded into namespaces
+		selection := policy.NewNamespaceSelection(namespaces, nil, o.Namespaces)
+		selection.Namespaced = reader.Namespaced
+		references, diags := policy.ExpandNamespaceSelectors(references, selection)
+		resources, readDiags := reader.Read(ctx, references)
+
+		return references, resources, append(diags, readDiags...), nil
 	}
 
-	return cPs
+	resources, diags := o.readResourceFiles(policyOptions)
+	selection := policy.NewNamespaceSelection(resources, references, o.Namespaces)
+	references, refDiags := policy.ExpandNamespaceSelectors(references, selection)
+	resources, resDiags := policy.ExpandNamespaceSelectors(resources, selection)
+	diags = append(diags, refDiags...)
+
+	return references, resources, append(diags, resDiags...), nil
 }
 
-func getObjectTemplates(p policyv1.Policy) []unstructured.Unstructured {
-	slog.Info(fmt.Sprintf("extracting %s --->", p.Name))
-	cPolicies := getConfigurationPolicy(p)
+// readResourceFiles reads, builds, renders and expands the resource directories, kustomizations
+// and charts, or reads the must-gathers.
+func (o compareOptions) readResourceFiles(policyOptions policy.Options) ([]resource.Object, []resource.Diagnostic) {
+	if o.ResourceFormat == resource.FormatMustGather {
+		// a must-gather holds the cluster state, where Policies are not expanded
+		return resource.ReadMustGather(o.ResourceDirs)
+	}
 
-	var objT []unstructured.Unstructured
+	resources, diags := resource.ReadDirs(o.ResourceDirs)
+	kustomized, kustomizeDiags := kustomize.BuildDirs(o.ResourceKustomize)
+	resources = append(resources, kustomized...)
+	diags = append(diags, kustomizeDiags...)
+	rendered, helmDiags := helm.RenderDirs(o.ResourceHelm, helm.Options{
+		ReleaseName: o.ReleaseName, Namespace: o.ReleaseNamespace, ValuesFiles: o.HelmValues,
+	})
+	resources = append(resources, rendered...)
+	diags = append(diags, helmDiags...)
+	resources, expandDiags := o.expand(resources, policyOptions)
+
+	return resources, append(diags, expandDiags...)
+}
 
-	for _, cPolicy := range cPolicies {
-		for _, ot := range cPolicy.Spec.ObjectTemplates {
-			customResource := &unstructured.Unstructured{}
+// expand replaces the PolicyGenTemplates, PolicyGenerators and Policies with the CRs they generate.
+func (o compareOptions) expand(objects []resource.Object, policyOptions policy.Options) ([]resource.Object, []resource.Diagnostic) {
+	objects, diags := ztp.ExpandPolicyGenTemplates(objects, o.SourceCRDirs)
 
-			err := customResource.UnmarshalJSON(ot.ObjectDefinition.Raw)
-			if err != nil {
-				slog.Warn("could not convert raw ObjectTemplates to unstructured ObjectTemplates")
+	objects, generatorDiags := policy.ExpandPolicyGenerators(objects)
+	diags = append(diags, generatorDiags...)
 
-				continue
-			}
-
-			slog.Info(fmt.Sprintf("found CR %s", customResource.GetName()))
-			objT = append(objT, *customResource)
-		}
-	}
+	objects, policyDiags := policy.ExpandPolicies(objects, policyOptions)
+	diags = append(diags, policyDiags...)
 
-	return objT
+	return objects, diags
 }
 
-func equalUnstructuredList(setA []unstructured.Unstructured, setB []unstructured.Unstructured) bool {
+func equalUnstructuredList(setA []resource.Object, setB []resource.Object) bool {
 	mapA := make(map[string]string, len(setA))
 
 	for _, a := range setA {
@@ -264,3 +416,33 @@ func equalUnstructuredList(setA []unstructured.Unstructured, setB []unstructured
 
 	return true
 }
+
+// isInput is true for the directories, archives, git sources and standard input objects can be
+// read from.
+func isInput(path string) bool {
+	return path == resource.Stdin || util.IsDirectory(path) || resource.IsArchive(path) || resource.IsGitSource(path)
+}
+
+func countStdin(pathLists ...[]string) int {
+	count := 0
+
+	for _, paths := range pathLists {
+		for _, path := range paths {
+			if path == resource.Stdin {
+				count++
+			}
+		}
+	}
+
+	return count
+}
+
+func isOneOf(value string, values []string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
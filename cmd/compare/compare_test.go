@@ -1,55 +1,168 @@
 package compare
 
 import (
+	"bytes"
+	"errors"
 	"os"
-	"reflect"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
-func Test_yamlToUnstructured(t *testing.T) {
-	resourceNs := `
+func mustWriteTestDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o700); err != nil {
+			t.Fatalf("could not write test file: %v", err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("could not write test file: %v", err)
+		}
+	}
+
+	return dir
+}
+
+func TestNewCmdCompare(t *testing.T) {
+	reference := mustWriteTestDir(t, map[string]string{"cm.yaml": `
 apiVersion: v1
-kind: Namespace
+kind: ConfigMap
 metadata:
-  name: cnfdf28
-  labels:
-    name: cnfdf28
-`
+  name: cm
+  namespace: ns1
+data:
+  key: value
+`})
 
 	type args struct {
-		file string
+		resource string
+		flags    []string
 	}
 
 	tests := []struct {
-		name string
-		args args
-		want string
+		name    string
+		args    args
+		want    []string
+		wantErr error
 	}{
 		{
-			name: "pass in a CR and get back Unstructured",
-			args: args{file: mustGetTestFilePath(t, resourceNs)},
-			want: "Namespace",
+			name: "matching resource",
+			args: args{resource: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+  namespace: ns1
+  uid: 6b1a6ad4-0a4c-4d1b-8d2e-6a1c1f0e9a7b
+data:
+  key: value
+  other: value
+`},
+			want: []string{"matched: v1/ConfigMap ns1/cm", "1 matched, 0 different"},
+		},
+		{
+			name: "different resource in exact mode",
+			args: args{
+				resource: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+  namespace: ns1
+data:
+  key: value
+  other: value
+`,
+				flags: []string{"--match", "exact"},
+			},
+			want:    []string{"different: v1/ConfigMap ns1/cm", "+ .data.other: value"},
+			wantErr: errDifferencesFound,
+		},
+		{
+			name: "invalid input in strict mode",
+			args: args{
+				resource: "kind: ConfigMap\n",
+				flags:    []string{"--strict"},
+			},
+			want:    []string{"1 invalid input(s):"},
+			wantErr: errInvalidInput,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := yamlToUnstructured(tt.args.file); !reflect.DeepEqual(got.GetKind(), tt.want) {
-				t.Errorf("yamlToUnstructured() = %v, want %v", got, tt.want)
+			resource := mustWriteTestDir(t, map[string]string{"cm.yaml": tt.args.resource})
+
+			out := &bytes.Buffer{}
+			cmd := NewCmdCompare()
+			cmd.SetOut(out)
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(append([]string{"--reference", reference, "--resource", resource}, tt.args.flags...))
+
+			if err := cmd.Execute(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("Execute() = %s, want it to contain %q", out.String(), want)
+				}
 			}
 		})
 	}
 }
 
-func mustGetTestFilePath(t *testing.T, cr string) string {
-	t.Helper()
-	testDir := t.TempDir()
-	file, _ := os.CreateTemp(testDir, "testfile")
+func TestNewCmdCompare_mustGather(t *testing.T) {
+	reference := mustWriteTestDir(t, map[string]string{"cm.yaml": `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+  namespace: ns1
+data:
+  key: value
+`})
+	mustGather := mustWriteTestDir(t, map[string]string{
+		"image/namespaces/ns1/ns1.yaml": `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: ns1
+`,
+		"image/namespaces/ns1/core/configmaps.yaml": `
+apiVersion: v1
+kind: ConfigMapList
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: cm
+    namespace: ns1
+  data:
+    key: value
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: kube-root-ca.crt
+    namespace: ns1
+`,
+	})
+
+	out := &bytes.Buffer{}
+	cmd := NewCmdCompare()
+	cmd.SetOut(out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"--reference", reference, "--resource", mustGather, "--resource-format", "must-gather"})
 
-	_, err := file.WriteString(cr)
-	if err != nil {
-		panic("could not create file")
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("Execute() error = %v, want the objects without a reference ignored", err)
 	}
 
-	return file.Name()
+	if !strings.Contains(out.String(), "1 matched, 0 different, 0 missing, 0 unexpected") {
+		t.Errorf("Execute() = %s, want the ConfigMap matched only", out.String())
+	}
 }
@@ -0,0 +1,202 @@
+// Package cluster reads from a live cluster the objects that correspond to reference objects, so
+// they can be compared without capturing the cluster state to a directory first.
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+
+	"github.com/openshift-kni/reference-validator/pkg/compare"
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// Reader fetches live objects through a dynamic client.
+type Reader struct {
+	client dynamic.Interface
+	mapper meta.RESTMapper
+	// host is the API server URL the sources of the objects read are relative to.
+	host string
+}
+
+// NewReader returns a Reader of the cluster served at host, mapping the kinds of the references to
+// their resources with mapper.
+func NewReader(client dynamic.Interface, mapper meta.RESTMapper, host string) *Reader {
+	return &Reader{client: client, mapper: mapper, host: host}
+}
+
+// NewReaderFromFlags returns a Reader of the cluster selected by the kubeconfig flags.
+func NewReaderFromFlags(flags *genericclioptions.ConfigFlags) (*Reader, error) {
+	config, err := flags.ToRESTConfig()
+	if err != nil {
+		return nil, fmt.Errorf("could not load kubeconfig: %w", err)
+	}
+
+	mapper, err := flags.ToRESTMapper()
+	if err != nil {
+		return nil, fmt.Errorf("could not discover cluster resources: %w", err)
+	}
+
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("could not create cluster client: %w", err)
+	}
+
+	return NewReader(client, mapper, config.Host), nil
+}
+
+// Read fetches the live object of every reference by group, version, kind, namespace and name. A
+// reference with a templated name or namespace lists the objects of its kind instead, keeping the
+// ones matching its templates. Objects that do not exist, including those of kinds the cluster
+// does not serve, are left out so that their references are reported missing.
+func (r *Reader) Read(ctx context.Context, references []resource.Object) ([]resource.Object, []resource.Diagnostic) {
+	var (
+		objects []resource.Object
+		diags   []resource.Diagnostic
+	)
+
+	read := map[object.ObjMetadata]bool{}
+
+	for i := range references {
+		ref := &references[i]
+
+		found, err := r.fetch(ctx, ref)
+		if err != nil {
+			diags = append(diags, resource.NewDiagnostic(ref.Source, fmt.Sprintf("could not read %s %s from the cluster: %v",
+				ref.GetKind(), identity(ref.GetNamespace(), ref.GetName()), err)))
+
+			continue
+		}
+
+		for _, o := range found {
+			id := object.UnstructuredToObjMetadata(&o.Unstructured)
+			if read[id] {
+				continue
+			}
+
+			read[id] = true
+			objects = append(objects, o)
+		}
+	}
+
+	return objects, diags
+}
+
+// Namespaces lists the Namespaces of the cluster.
+func (r *Reader) Namespaces(ctx context.Context) ([]resource.Object, error) {
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+
+	list, err := r.client.Resource(gvr).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("could not list namespaces: %w", err)
+	}
+
+	namespaces := make([]resource.Object, 0, len(list.Items))
+	for i := range list.Items {
+		namespaces = append(namespaces, r.newObject(gvr, &list.Items[i]))
+	}
+
+	return namespaces, nil
+}
+
+// Namespaced is true for the kinds the cluster serves as namespaced resources, and for the kinds it
+// does not serve, as a namespaceSelector only applies to namespaced objects.
+func (r *Reader) Namespaced(gvk schema.GroupVersionKind) bool {
+	mapping, err := r.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return true
+	}
+
+	return mapping.Scope.Name() == meta.RESTScopeNameNamespace
+}
+
+func (r *Reader) fetch(ctx context.Context, ref *resource.Object) ([]resource.Object, error) {
+	gvk := ref.GroupVersionKind()
+
+	mapping, err := r.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		slog.Info(fmt.Sprintf("kind %s is not served by the cluster", gvk))
+
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not map kind %s: %w", gvk, err)
+	}
+
+	namespace := ref.GetNamespace()
+	if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+		namespace = ""
+	}
+
+	if !compare.IsTemplated(namespace) && !compare.IsTemplated(ref.GetName()) {
+		live, err := r.client.Resource(mapping.Resource).Namespace(namespace).Get(ctx, ref.GetName(), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("could not get object: %w", err)
+		}
+
+		return []resource.Object{r.newObject(mapping.Resource, live)}, nil
+	}
+
+	if compare.IsTemplated(namespace) {
+		namespace = metav1.NamespaceAll
+	}
+
+	list, err := r.client.Resource(mapping.Resource).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not list objects: %w", err)
+	}
+
+	var found []resource.Object
+
+	for i := range list.Items {
+		o := r.newObject(mapping.Resource, &list.Items[i])
+		if compare.MatchesIdentity(ref, &o) {
+			found = append(found, o)
+		}
+	}
+
+	return found, nil
+}
+
+// newObject returns the live object with the API URL it was read from as its source.
+func (r *Reader) newObject(gvr schema.GroupVersionResource, live *unstructured.Unstructured) resource.Object {
+	prefix := path.Join("/apis", gvr.Group, gvr.Version)
+	if gvr.Group == "" {
+		prefix = path.Join("/api", gvr.Version)
+	}
+
+	if live.GetNamespace() != "" {
+		prefix = path.Join(prefix, "namespaces", live.GetNamespace())
+	}
+
+	return resource.Object{
+		Unstructured: *live,
+		Source:       resource.Source{File: r.host + path.Join(prefix, gvr.Resource, live.GetName())},
+	}
+}
+
+func identity(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+
+	return namespace + "/" + name
+}
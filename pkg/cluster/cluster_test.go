@@ -0,0 +1,144 @@
+package cluster
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func newTestObject(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+
+	return u
+}
+
+func newTestReader(objects ...runtime.Object) *Reader {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		{Version: "v1", Resource: "configmaps"}: "ConfigMapList",
+		{Version: "v1", Resource: "namespaces"}: "NamespaceList",
+	}, objects...)
+
+	return NewReader(client, mapper, "https://api.example:6443")
+}
+
+func TestReader_Read(t *testing.T) {
+	live := []runtime.Object{
+		newTestObject("v1", "Namespace", "", "sites"),
+		newTestObject("v1", "ConfigMap", "sites", "site-east"),
+		newTestObject("v1", "ConfigMap", "sites", "site-west"),
+		newTestObject("v1", "ConfigMap", "sites", "other"),
+		newTestObject("v1", "ConfigMap", "apps", "site-north"),
+	}
+
+	type args struct {
+		references []*unstructured.Unstructured
+	}
+
+	tests := []struct {
+		name      string
+		args      args
+		want      []string
+		wantDiags int
+	}{
+		{
+			name: "objects are fetched by kind, namespace and name",
+			args: args{references: []*unstructured.Unstructured{
+				newTestObject("v1", "Namespace", "", "sites"),
+				newTestObject("v1", "ConfigMap", "sites", "site-east"),
+			}},
+			want: []string{
+				"https://api.example:6443/api/v1/namespaces/sites",
+				"https://api.example:6443/api/v1/namespaces/sites/configmaps/site-east",
+			},
+		},
+		{
+			name: "absent objects and kinds the cluster does not serve are left out",
+			args: args{references: []*unstructured.Unstructured{
+				newTestObject("v1", "ConfigMap", "sites", "absent"),
+				newTestObject("example.com/v1", "Widget", "sites", "site-east"),
+			}},
+		},
+		{
+			name: "templated names list the objects of the namespace matching the template",
+			args: args{references: []*unstructured.Unstructured{
+				newTestObject("v1", "ConfigMap", "sites", `site-{{ .name | regex "[a-z]+" }}`),
+			}},
+			want: []string{
+				"https://api.example:6443/api/v1/namespaces/sites/configmaps/site-east",
+				"https://api.example:6443/api/v1/namespaces/sites/configmaps/site-west",
+			},
+		},
+		{
+			name: "templated namespaces list the objects of every namespace, once",
+			args: args{references: []*unstructured.Unstructured{
+				newTestObject("v1", "ConfigMap", "{{ .namespace }}", "site-{{ .name }}"),
+				newTestObject("v1", "ConfigMap", "sites", "site-east"),
+			}},
+			want: []string{
+				"https://api.example:6443/api/v1/namespaces/apps/configmaps/site-north",
+				"https://api.example:6443/api/v1/namespaces/sites/configmaps/site-east",
+				"https://api.example:6443/api/v1/namespaces/sites/configmaps/site-west",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			references := make([]resource.Object, 0, len(tt.args.references))
+			for _, ref := range tt.args.references {
+				references = append(references, resource.Object{Unstructured: *ref})
+			}
+
+			got, diags := newTestReader(live...).Read(context.Background(), references)
+			if len(diags) != tt.wantDiags {
+				t.Errorf("Read() diagnostics = %v, want %d", diags, tt.wantDiags)
+			}
+
+			sources := make([]string, 0, len(got))
+			for _, o := range got {
+				sources = append(sources, o.Source.File)
+			}
+
+			sort.Strings(sources)
+
+			if len(sources) != len(tt.want) {
+				t.Fatalf("Read() = %v, want %v", sources, tt.want)
+			}
+
+			for i := range sources {
+				if sources[i] != tt.want[i] {
+					t.Errorf("Read() = %v, want %v", sources, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReader_Namespaces(t *testing.T) {
+	reader := newTestReader(newTestObject("v1", "Namespace", "", "sites"), newTestObject("v1", "ConfigMap", "sites", "site-east"))
+
+	namespaces, err := reader.Namespaces(context.Background())
+	if err != nil || len(namespaces) != 1 || namespaces[0].GetName() != "sites" {
+		t.Errorf("Namespaces() = %v, error = %v, want the sites Namespace", namespaces, err)
+	}
+
+	if !reader.Namespaced(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}) ||
+		reader.Namespaced(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}) ||
+		!reader.Namespaced(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Unserved"}) {
+		t.Errorf("Namespaced() is not true for ConfigMaps and unserved kinds only")
+	}
+}
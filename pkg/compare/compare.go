@@ -0,0 +1,312 @@
+// Package compare correlates a set of reference CRs with a set of resources and reports, for
+// every reference, whether a resource matches it and which fields differ.
+package compare
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+)
+
+// ResultAPIVersion versions the serialized Result schema. Bump it on any incompatible change
+// to the fields below so that tools consuming archived results can tell them apart.
+const (
+	ResultAPIVersion = "reference-validator.openshift.io/v1alpha1"
+	ResultKind       = "CompareResult"
+)
+
+// Status is the outcome of the comparison of a single object.
+type Status string
+
+const (
+	StatusMatched    Status = "matched"
+	StatusDifferent  Status = "different"
+	StatusMissing    Status = "missing"
+	StatusUnexpected Status = "unexpected"
+	// StatusAbsent is a mustnothave reference without any resource, as required.
+	StatusAbsent Status = "absent"
+	// StatusForbidden is a mustnothave reference that has a resource.
+	StatusForbidden Status = "forbidden"
+)
+
+// Passed is true for the statuses that do not fail a comparison.
+func (s Status) Passed() bool {
+	return s == StatusMatched || s == StatusAbsent
+}
+
+// ObjectResult is the outcome of comparing a single reference CR with its resource counterpart.
+type ObjectResult struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	Status     Status `json:"status"`
+	// ComplianceType is the compliance type of references extracted from a Policy.
+	ComplianceType string           `json:"complianceType,omitempty"`
+	Reference      *resource.Source `json:"reference,omitempty"`
+	Resource       *resource.Source `json:"resource,omitempty"`
+	Differences    []FieldDiff      `json:"differences,omitempty"`
+	// Policy and Template are the Policy and policy-template references were extracted from.
+	Policy   string `json:"policy,omitempty"`
+	Template string `json:"template,omitempty"`
+	// BlockedBy lists the dependencies of the template that the comparison does not leave in the
+	// compliance state they require, so that ACM would not apply the template yet.
+	BlockedBy []string `json:"blockedBy,omitempty"`
+
+	// ReferenceObject and ResourceObject are the compared content of each side, without the
+	// ignored fields and, unless both are matched exactly, without the resource fields absent from
+	// the reference. They are only set when both sides exist.
+	ReferenceObject map[string]interface{} `json:"-"`
+	ResourceObject  map[string]interface{} `json:"-"`
+
+	dependencies  []resource.Dependency
+	ignorePending bool
+}
+
+// ComponentStatus is the outcome of looking for a reference component in the resources.
+type ComponentStatus string
+
+const (
+	ComponentPresent ComponentStatus = "present"
+	ComponentMissing ComponentStatus = "missing"
+	// ComponentSkipped is an optional component without any resource.
+	ComponentSkipped ComponentStatus = "skipped"
+	// ComponentConflicting is a "one of" component with resources for several alternatives, when
+	// exactly one is expected.
+	ComponentConflicting ComponentStatus = "conflicting"
+)
+
+// ComponentResult is the outcome of looking for a group of reference CRs in the resources, as
+// described by the metadata of a reference.
+type ComponentResult struct {
+	Part     string          `json:"part"`
+	Name     string          `json:"name"`
+	Required bool            `json:"required"`
+	Status   ComponentStatus `json:"status"`
+	// Alternative is the "one of" alternative found in the resources.
+	Alternative string `json:"alternative,omitempty"`
+	// Alternatives lists the "one of" alternatives of a component that was not found, or the
+	// alternatives found for a conflicting component.
+	Alternatives []string `json:"alternatives,omitempty"`
+}
+
+// ID identifies the component in human-readable output.
+func (c ComponentResult) ID() string {
+	return c.Part + "/" + c.Name
+}
+
+func (c ComponentResult) String() string {
+	line := fmt.Sprintf("%s component: %s", c.Status, c.ID())
+
+	switch {
+	case c.Alternative != "":
+		line += fmt.Sprintf(" (%s)", c.Alternative)
+	case c.Status == ComponentConflicting:
+		line += fmt.Sprintf(" (found: %s)", strings.Join(c.Alternatives, ", "))
+	case len(c.Alternatives) > 0:
+		line += fmt.Sprintf(" (one of: %s)", strings.Join(c.Alternatives, ", "))
+	}
+
+	return line
+}
+
+// Summary counts the objects of a Result by status.
+type Summary struct {
+	Matched               int `json:"matched"`
+	Different             int `json:"different"`
+	Missing               int `json:"missing"`
+	Unexpected            int `json:"unexpected"`
+	Absent                int `json:"absent"`
+	Forbidden             int `json:"forbidden"`
+	MissingComponents     int `json:"missingComponents,omitempty"`
+	ConflictingComponents int `json:"conflictingComponents,omitempty"`
+}
+
+// Result is the outcome of comparing a whole reference set with a whole resource set.
+type Result struct {
+	APIVersion string         `json:"apiVersion"`
+	Kind       string         `json:"kind"`
+	Summary    Summary        `json:"summary"`
+	Objects    []ObjectResult `json:"objects"`
+	// Components is only set when the reference describes its components.
+	Components []ComponentResult `json:"components,omitempty"`
+}
+
+func newObjectResult(o resource.Object, status Status) ObjectResult {
+	return ObjectResult{
+		APIVersion:     o.GetAPIVersion(),
+		Kind:           o.GetKind(),
+		Namespace:      o.GetNamespace(),
+		Name:           o.GetName(),
+		Status:         status,
+		ComplianceType: string(o.ComplianceType),
+	}
+}
+
+// newReferenceResult is the ObjectResult of a reference, which carries the Policy it was
+// extracted from.
+func newReferenceResult(reference resource.Object, status Status) ObjectResult {
+	objResult := newObjectResult(reference, status)
+	objResult.Reference = &reference.Source
+	objResult.Policy, objResult.Template = reference.Policy, reference.Template
+	objResult.dependencies, objResult.ignorePending = reference.Dependencies, reference.IgnorePending
+
+	return objResult
+}
+
+// ID identifies the object in human-readable output.
+func (o ObjectResult) ID() string {
+	if o.Namespace == "" {
+		return fmt.Sprintf("%s/%s %s", o.APIVersion, o.Kind, o.Name)
+	}
+
+	return fmt.Sprintf("%s/%s %s/%s", o.APIVersion, o.Kind, o.Namespace, o.Name)
+}
+
+// Config tunes how each reference is compared with its resource.
+type Config struct {
+	// Ignore lists the fields stripped from both sides before comparing them.
+	Ignore IgnoreList
+	// Match is used for references without a compliance type, and defaults to MatchSubset.
+	Match MatchMode
+	// IgnoreUnexpected leaves out the resources that no reference correlates with, for resources
+	// that capture a whole cluster rather than the objects expected to match the references.
+	IgnoreUnexpected bool
+}
+
+// Comparator compares reference sets with resource sets.
+type Comparator struct {
+	config Config
+}
+
+// NewComparator returns a Comparator using the given configuration.
+func NewComparator(config Config) *Comparator {
+	return &Comparator{config: config}
+}
+
+// Compare correlates the reference set with the resource set and reports the field differences
+// of each pair as well as the objects left unpaired.
+func (c *Comparator) Compare(references, resources []resource.Object) Result {
+	corr := correlate(references, resources)
+	result := Result{APIVersion: ResultAPIVersion, Kind: ResultKind, Objects: []ObjectResult{}}
+
+	for i := range corr.Pairs {
+		pair := corr.Pairs[i]
+		objResult := newReferenceResult(pair.Reference, StatusMatched)
+		// templated references are reported under the identity of the resource they matched
+		objResult.Namespace, objResult.Name = pair.Resource.GetNamespace(), pair.Resource.GetName()
+		objResult.Resource = &pair.Resource.Source
+
+		if mustNotHave(pair.Reference) {
+			objResult.Status = StatusForbidden
+			result.Objects = append(result.Objects, objResult)
+
+			continue
+		}
+
+		objResult.ReferenceObject = c.config.Ignore.strip(pair.Reference.Object)
+		objResult.ResourceObject = c.config.Ignore.strip(pair.Resource.Object)
+
+		objResult.Differences = diffByCompliance(pair.Reference, objResult.ReferenceObject, objResult.ResourceObject, c.config.Match)
+		objResult.ResourceObject = pruneByCompliance(pair.Reference, objResult.ReferenceObject, objResult.ResourceObject, c.config.Match)
+
+		if len(objResult.Differences) > 0 {
+			objResult.Status = StatusDifferent
+		}
+
+		result.Objects = append(result.Objects, objResult)
+	}
+
+	for i := range corr.Missing {
+		objResult := newReferenceResult(corr.Missing[i], StatusMissing)
+
+		if mustNotHave(corr.Missing[i]) {
+			objResult.Status = StatusAbsent
+		}
+
+		result.Objects = append(result.Objects, objResult)
+	}
+
+	if c.config.IgnoreUnexpected {
+		corr.Unexpected = nil
+	}
+
+	for i := range corr.Unexpected {
+		objResult := newObjectResult(corr.Unexpected[i], StatusUnexpected)
+		objResult.Resource = &corr.Unexpected[i].Source
+		result.Objects = append(result.Objects, objResult)
+	}
+
+	sort.SliceStable(result.Objects, func(i, j int) bool {
+		return result.Objects[i].ID() < result.Objects[j].ID()
+	})
+
+	result.evaluateDependencies()
+	result.Summarize()
+
+	return result
+}
+
+// Summarize counts the objects and missing components of the result into its Summary, and must
+// be called again after they are changed.
+func (r *Result) Summarize() {
+	r.Summary = Summary{
+		Matched:    r.count(StatusMatched),
+		Different:  r.count(StatusDifferent),
+		Missing:    r.count(StatusMissing),
+		Unexpected: r.count(StatusUnexpected),
+		Absent:     r.count(StatusAbsent),
+		Forbidden:  r.count(StatusForbidden),
+	}
+
+	for _, c := range r.Components {
+		switch c.Status {
+		case ComponentMissing:
+			r.Summary.MissingComponents++
+		case ComponentConflicting:
+			r.Summary.ConflictingComponents++
+		case ComponentPresent, ComponentSkipped:
+		}
+	}
+}
+
+func (r Result) count(status Status) int {
+	total := 0
+
+	for _, o := range r.Objects {
+		if o.Status == status {
+			total++
+		}
+	}
+
+	return total
+}
+
+// Passed is true when every reference has a matching resource, no mustnothave reference has one,
+// no resource is left over, no required component is missing and no component has several
+// alternatives.
+func (r Result) Passed() bool {
+	return r.Summary.Matched+r.Summary.Absent == len(r.Objects) && r.Summary.MissingComponents == 0 &&
+		r.Summary.ConflictingComponents == 0
+}
+
+func (s Summary) String() string {
+	summary := fmt.Sprintf("Summary: %d matched, %d different, %d missing, %d unexpected",
+		s.Matched, s.Different, s.Missing, s.Unexpected)
+
+	if s.Absent > 0 || s.Forbidden > 0 {
+		summary += fmt.Sprintf(", %d absent, %d forbidden", s.Absent, s.Forbidden)
+	}
+
+	if s.MissingComponents > 0 {
+		summary += fmt.Sprintf(", %d missing component(s)", s.MissingComponents)
+	}
+
+	if s.ConflictingComponents > 0 {
+		summary += fmt.Sprintf(", %d conflicting component(s)", s.ConflictingComponents)
+	}
+
+	return summary
+}
@@ -0,0 +1,210 @@
+package compare
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+
+	configurationPolicyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+)
+
+// isComplianceType compares compliance types case-insensitively, as the config-policy-controller
+// accepts musthave, Musthave and MustHave alike.
+func isComplianceType(value string, complianceType configurationPolicyv1.ComplianceType) bool {
+	return strings.EqualFold(value, string(complianceType))
+}
+
+// mustNotHave is true for references that must be absent from the resources.
+func mustNotHave(o resource.Object) bool {
+	return isComplianceType(string(o.ComplianceType), configurationPolicyv1.MustNotHave)
+}
+
+// diffByCompliance returns the differences of a correlated pair the way the config-policy-controller
+// would judge them: musthave as a subset match and mustonlyhave as an exact match of the fields
+// listed in the reference, labels and annotations following the metadata compliance type in both
+// cases. References without a compliance type use the match mode.
+func diffByCompliance(ref resource.Object, reference, resource map[string]interface{}, mode MatchMode) []FieldDiff {
+	var diffs []FieldDiff
+
+	switch {
+	case isComplianceType(string(ref.ComplianceType), configurationPolicyv1.MustOnlyHave):
+		diffs = diffOnlyHave(reference, resource, metadataMustOnlyHave(ref))
+	case isComplianceType(string(ref.ComplianceType), configurationPolicyv1.MustHave):
+		diffs = diffMustHave(reference, resource, metadataMustOnlyHave(ref))
+	default:
+		return diffObjectsWithMode(reference, resource, mode)
+	}
+
+	sort.SliceStable(diffs, func(i, j int) bool {
+		return diffs[i].Path < diffs[j].Path
+	})
+
+	return diffs
+}
+
+// pruneByCompliance returns the fields of the resource that diffByCompliance checks against the
+// reference, so that the fields a subset match allows in the resource are not shown as differences.
+// References matched exactly keep the whole resource.
+func pruneByCompliance(ref resource.Object, reference, resource map[string]interface{}, mode MatchMode) map[string]interface{} {
+	switch {
+	case isComplianceType(string(ref.ComplianceType), configurationPolicyv1.MustOnlyHave):
+		return pruneObject(reference, resource, true, metadataMustOnlyHave(ref))
+	case isComplianceType(string(ref.ComplianceType), configurationPolicyv1.MustHave):
+		return pruneObject(reference, resource, false, metadataMustOnlyHave(ref))
+	case mode == MatchExact:
+		return resource
+	default:
+		return pruneObject(reference, resource, false, false)
+	}
+}
+
+// pruneObject keeps the top-level fields of the resource listed in the reference, whole when
+// onlyHave is set and pruned to the reference otherwise. Labels and annotations are kept whole when
+// metadataOnlyHave is set.
+func pruneObject(reference, resource map[string]interface{}, onlyHave, metadataOnlyHave bool) map[string]interface{} {
+	pruned := make(map[string]interface{}, len(reference))
+
+	for key, refValue := range reference {
+		resValue, exists := resource[key]
+
+		switch {
+		case !exists:
+			continue
+		case key == "metadata":
+			pruned[key] = pruneMetadata(refValue, resValue, metadataOnlyHave)
+		case onlyHave:
+			pruned[key] = resValue
+		default:
+			pruned[key] = pruneSubset(refValue, resValue)
+		}
+	}
+
+	return pruned
+}
+
+// pruneMetadata keeps the metadata fields of the resource listed in the reference, as diffMetadata
+// checks them.
+func pruneMetadata(reference, resource interface{}, metadataOnlyHave bool) interface{} {
+	refMeta, refOk := reference.(map[string]interface{})
+	resMeta, resOk := resource.(map[string]interface{})
+
+	if !refOk || !resOk {
+		return resource
+	}
+
+	pruned := make(map[string]interface{}, len(refMeta))
+
+	for key, refValue := range refMeta {
+		resValue, exists := resMeta[key]
+
+		switch {
+		case !exists:
+			continue
+		case metadataOnlyHave && (key == "labels" || key == "annotations"):
+			pruned[key] = resValue
+		default:
+			pruned[key] = pruneSubset(refValue, resValue)
+		}
+	}
+
+	return pruned
+}
+
+// metadataMustOnlyHave resolves how labels and annotations are checked, which defaults to the
+// compliance type of the object template.
+func metadataMustOnlyHave(ref resource.Object) bool {
+	if ref.MetadataComplianceType != "" {
+		return isComplianceType(string(ref.MetadataComplianceType), configurationPolicyv1.MustOnlyHave)
+	}
+
+	return isComplianceType(string(ref.ComplianceType), configurationPolicyv1.MustOnlyHave)
+}
+
+// diffOnlyHave requires every top-level field listed in the reference to be identical in the
+// resource, lists included. Fields not listed in the reference are not checked.
+func diffOnlyHave(reference, resource map[string]interface{}, metadataOnlyHave bool) []FieldDiff {
+	var diffs []FieldDiff
+
+	for key, refValue := range reference {
+		path := joinFieldPath("", key)
+
+		resValue, exists := resource[key]
+		if !exists {
+			diffs = append(diffs, FieldDiff{Path: path, Type: DiffRemoved, Reference: refValue})
+
+			continue
+		}
+
+		if key == "metadata" {
+			diffs = append(diffs, diffMetadata(refValue, resValue, metadataOnlyHave)...)
+
+			continue
+		}
+
+		diffs = append(diffs, diffValues(path, refValue, resValue)...)
+	}
+
+	return diffs
+}
+
+// diffMustHave requires every field listed in the reference to be present and equal in the
+// resource, which may hold more, except for the labels and annotations when metadataOnlyHave is set.
+func diffMustHave(reference, resource map[string]interface{}, metadataOnlyHave bool) []FieldDiff {
+	var diffs []FieldDiff
+
+	for key, refValue := range reference {
+		path := joinFieldPath("", key)
+
+		resValue, exists := resource[key]
+		if !exists {
+			diffs = append(diffs, FieldDiff{Path: path, Type: DiffRemoved, Reference: refValue})
+
+			continue
+		}
+
+		if key == "metadata" {
+			diffs = append(diffs, diffMetadata(refValue, resValue, metadataOnlyHave)...)
+
+			continue
+		}
+
+		diffs = append(diffs, diffSubset(path, refValue, resValue)...)
+	}
+
+	return diffs
+}
+
+// diffMetadata checks labels and annotations exactly when metadataOnlyHave is set, and every other
+// metadata field as a subset since the API server populates most of them.
+func diffMetadata(reference, resource interface{}, metadataOnlyHave bool) []FieldDiff {
+	refMeta, refOk := reference.(map[string]interface{})
+	resMeta, resOk := resource.(map[string]interface{})
+
+	if !refOk || !resOk {
+		return diffValues(".metadata", reference, resource)
+	}
+
+	var diffs []FieldDiff
+
+	for key, refValue := range refMeta {
+		path := joinFieldPath(".metadata", key)
+
+		resValue, exists := resMeta[key]
+		if !exists {
+			diffs = append(diffs, FieldDiff{Path: path, Type: DiffRemoved, Reference: refValue})
+
+			continue
+		}
+
+		if metadataOnlyHave && (key == "labels" || key == "annotations") {
+			diffs = append(diffs, diffValues(path, refValue, resValue)...)
+
+			continue
+		}
+
+		diffs = append(diffs, diffSubset(path, refValue, resValue)...)
+	}
+
+	return diffs
+}
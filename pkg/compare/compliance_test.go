@@ -0,0 +1,137 @@
+package compare
+
+import (
+	"testing"
+
+	"github.com/openshift-kni/reference-validator/pkg/policy"
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+)
+
+func TestComparator_Compare_complianceTypes(t *testing.T) {
+	policyYAML := `
+apiVersion: policy.open-cluster-management.io/v1
+kind: Policy
+metadata:
+  name: policy1
+  namespace: ztp
+spec:
+  disabled: false
+  policy-templates:
+  - objectDefinition:
+      apiVersion: policy.open-cluster-management.io/v1
+      kind: ConfigurationPolicy
+      metadata:
+        name: policy1-config
+      spec:
+        object-templates:
+        - complianceType: musthave
+          objectDefinition:
+            apiVersion: v1
+            kind: ConfigMap
+            metadata:
+              name: musthave
+              namespace: ns1
+            data:
+              list: "a"
+        - complianceType: mustonlyhave
+          objectDefinition:
+            apiVersion: v1
+            kind: ConfigMap
+            metadata:
+              name: mustonlyhave
+              namespace: ns1
+            data:
+              key: "a"
+        - complianceType: mustnothave
+          objectDefinition:
+            apiVersion: v1
+            kind: ConfigMap
+            metadata:
+              name: forbidden
+              namespace: ns1
+        - complianceType: musthave
+          metadataComplianceType: mustonlyhave
+          objectDefinition:
+            apiVersion: v1
+            kind: ConfigMap
+            metadata:
+              name: labelled
+              namespace: ns1
+              labels:
+                a: "1"
+        - complianceType: MustNotHave
+          objectDefinition:
+            apiVersion: v1
+            kind: ConfigMap
+            metadata:
+              name: absent
+              namespace: ns1
+`
+	resources := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: musthave
+  namespace: ns1
+data:
+  list: "a"
+  extra: "b"
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: mustonlyhave
+  namespace: ns1
+data:
+  key: "a"
+  extra: "b"
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: forbidden
+  namespace: ns1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: labelled
+  namespace: ns1
+  labels:
+    a: "1"
+    b: "2"
+`
+
+	references, diags := resource.Decode([]byte(policyYAML), "policy.yaml")
+	references, policyDiags := policy.ExpandPolicies(references, policy.Options{})
+	diags = append(diags, policyDiags...)
+
+	if len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics %v", diags)
+	}
+
+	resourceObjects, _ := resource.Decode([]byte(resources), "resources.yaml")
+	rep := NewComparator(Config{Match: MatchExact}).Compare(references, resourceObjects)
+
+	want := map[string]Status{
+		"musthave":     StatusMatched,
+		"mustonlyhave": StatusDifferent,
+		"forbidden":    StatusForbidden,
+		"absent":       StatusAbsent,
+		"labelled":     StatusDifferent,
+	}
+
+	if len(rep.Objects) != len(want) {
+		t.Fatalf("Compare() = %+v, want %d objects", rep.Objects, len(want))
+	}
+
+	for _, o := range rep.Objects {
+		if o.Status != want[o.Name] {
+			t.Errorf("Compare() status of %s = %s, want %s", o.Name, o.Status, want[o.Name])
+		}
+	}
+
+	if rep.Passed() {
+		t.Errorf("Compare() passed with a forbidden object")
+	}
+}
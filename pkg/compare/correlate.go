@@ -0,0 +1,102 @@
+package compare
+
+import (
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// objectPair is a reference CR and the resource it correlates with.
+type objectPair struct {
+	ID        object.ObjMetadata
+	Reference resource.Object
+	Resource  resource.Object
+}
+
+// correlation is the outcome of pairing a reference set with a resource set.
+type correlation struct {
+	Pairs []objectPair
+	// Missing are the reference objects without any resource counterpart.
+	Missing []resource.Object
+	// Unexpected are the resources that no reference object correlates with.
+	Unexpected []resource.Object
+}
+
+// correlate pairs every reference with the resource sharing its group, kind, namespace and name.
+// When several resources share an identity, the first one read is used and the others are unexpected.
+// A reference with a templated name or namespace is paired with every resource of its group and kind
+// that matches the template and is not paired with another reference.
+func correlate(references, resources []resource.Object) correlation {
+	resourceIndex := make(map[object.ObjMetadata]int, len(resources))
+	result := correlation{}
+
+	for i := range resources {
+		id := object.UnstructuredToObjMetadata(&resources[i].Unstructured)
+		if _, exists := resourceIndex[id]; exists {
+			result.Unexpected = append(result.Unexpected, resources[i])
+
+			continue
+		}
+
+		resourceIndex[id] = i
+	}
+
+	paired := make(map[object.ObjMetadata]bool, len(references))
+
+	var templated []int
+
+	for i := range references {
+		if IsTemplated(references[i].GetNamespace()) || IsTemplated(references[i].GetName()) {
+			templated = append(templated, i)
+
+			continue
+		}
+
+		id := object.UnstructuredToObjMetadata(&references[i].Unstructured)
+
+		idx, exists := resourceIndex[id]
+		if !exists {
+			result.Missing = append(result.Missing, references[i])
+
+			continue
+		}
+
+		paired[id] = true
+		result.Pairs = append(result.Pairs, objectPair{ID: id, Reference: references[i], Resource: resources[idx]})
+	}
+
+	for _, i := range templated {
+		found := false
+
+		for j := range resources {
+			id := object.UnstructuredToObjMetadata(&resources[j].Unstructured)
+			if resourceIndex[id] != j || paired[id] || !MatchesIdentity(&references[i], &resources[j]) {
+				continue
+			}
+
+			found = true
+			paired[id] = true
+			result.Pairs = append(result.Pairs, objectPair{ID: id, Reference: references[i], Resource: resources[j]})
+		}
+
+		if !found {
+			result.Missing = append(result.Missing, references[i])
+		}
+	}
+
+	for i := range resources {
+		id := object.UnstructuredToObjMetadata(&resources[i].Unstructured)
+		if resourceIndex[id] == i && !paired[id] {
+			result.Unexpected = append(result.Unexpected, resources[i])
+		}
+	}
+
+	return result
+}
+
+// MatchesIdentity is true when the resource has the group and kind of the templated reference, and
+// a namespace and name matching its templates.
+func MatchesIdentity(reference, res *resource.Object) bool {
+	return reference.GroupVersionKind().GroupKind() == res.GroupVersionKind().GroupKind() &&
+		matchesTemplate(reference.GetNamespace(), res.GetNamespace()) &&
+		matchesTemplate(reference.GetName(), res.GetName())
+}
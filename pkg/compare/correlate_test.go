@@ -0,0 +1,134 @@
+package compare
+
+import (
+	"testing"
+
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newTestObject(apiVersion, kind, namespace, name string) resource.Object {
+	u := unstructured.Unstructured{Object: map[string]interface{}{}}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+
+	return resource.Object{Unstructured: u}
+}
+
+func Test_correlate(t *testing.T) {
+	type args struct {
+		references []resource.Object
+		resources  []resource.Object
+	}
+
+	tests := []struct {
+		name           string
+		args           args
+		wantPairs      int
+		wantMissing    []string
+		wantUnexpected []string
+	}{
+		{
+			name: "objects are paired by group, kind, namespace and name",
+			args: args{
+				references: []resource.Object{
+					newTestObject("v1", "Namespace", "", "ns1"),
+					newTestObject("apps/v1", "Deployment", "ns1", "app"),
+				},
+				resources: []resource.Object{
+					newTestObject("apps/v1", "Deployment", "ns1", "app"),
+					newTestObject("v1", "Namespace", "", "ns1"),
+				},
+			},
+			wantPairs: 2,
+		},
+		{
+			name: "unpaired references are missing and unpaired resources are unexpected",
+			args: args{
+				references: []resource.Object{
+					newTestObject("apps/v1", "Deployment", "ns1", "app"),
+				},
+				resources: []resource.Object{
+					newTestObject("apps/v1", "Deployment", "ns2", "app"),
+				},
+			},
+			wantMissing:    []string{"ns1"},
+			wantUnexpected: []string{"ns2"},
+		},
+		{
+			name: "duplicated resources are unexpected",
+			args: args{
+				references: []resource.Object{
+					newTestObject("v1", "ConfigMap", "ns1", "cm"),
+				},
+				resources: []resource.Object{
+					newTestObject("v1", "ConfigMap", "ns1", "cm"),
+					newTestObject("v1", "ConfigMap", "ns1", "cm"),
+				},
+			},
+			wantPairs:      1,
+			wantUnexpected: []string{"ns1"},
+		},
+		{
+			name: "templated names are paired with every matching resource not paired by name",
+			args: args{
+				references: []resource.Object{
+					newTestObject("v1", "ConfigMap", `{{ .namespace | regex "site-.*" }}`, "{{ .name }}"),
+					newTestObject("v1", "ConfigMap", "site-c", "cm"),
+					newTestObject("v1", "Secret", "{{ .namespace }}", "secret"),
+				},
+				resources: []resource.Object{
+					newTestObject("v1", "ConfigMap", "site-a", "cm"),
+					newTestObject("v1", "ConfigMap", "site-b", "cm"),
+					newTestObject("v1", "ConfigMap", "site-c", "cm"),
+					newTestObject("v1", "ConfigMap", "other", "cm"),
+				},
+			},
+			wantPairs:      3,
+			wantMissing:    []string{"{{ .namespace }}"},
+			wantUnexpected: []string{"other"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := correlate(tt.args.references, tt.args.resources)
+			if len(got.Pairs) != tt.wantPairs {
+				t.Errorf("correlate() pairs = %d, want %d", len(got.Pairs), tt.wantPairs)
+			}
+
+			if gotNs := namespacesOf(got.Missing); !equalStrings(gotNs, tt.wantMissing) {
+				t.Errorf("correlate() missing = %v, want %v", gotNs, tt.wantMissing)
+			}
+
+			if gotNs := namespacesOf(got.Unexpected); !equalStrings(gotNs, tt.wantUnexpected) {
+				t.Errorf("correlate() unexpected = %v, want %v", gotNs, tt.wantUnexpected)
+			}
+		})
+	}
+}
+
+func namespacesOf(uList []resource.Object) []string {
+	var namespaces []string
+	for _, u := range uList {
+		namespaces = append(namespaces, u.GetNamespace())
+	}
+
+	return namespaces
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,209 @@
+package compare
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+	policyv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+const (
+	policyKind    = "Policy"
+	policySetKind = "PolicySet"
+
+	// unknownCompliance is the state of the dependencies on Policies and templates without
+	// references.
+	unknownCompliance policyv1.ComplianceState = "Unknown"
+)
+
+// dependencyEvaluator derives the compliance state ACM would report for the Policies and templates
+// of a result: a template is Pending while a dependency is not in the state it requires, and
+// NonCompliant when one of its objects did not pass. A Policy is NonCompliant when one of its
+// applied templates is, and Pending when one of its templates is and does not ignore it.
+type dependencyEvaluator struct {
+	objects []ObjectResult
+	// templates indexes the objects of every policy/template, policies the templates of every
+	// Policy and names the templates of every template name.
+	templates map[string][]int
+	policies  map[string][]string
+	names     map[string][]string
+
+	states     map[string]policyv1.ComplianceState
+	evaluating map[string]bool
+}
+
+// evaluateDependencies marks the objects of the templates whose dependencies the result does not
+// satisfy, and orders the objects of every Policy after those of the Policies it depends on.
+// Dependencies in a cycle are never satisfied, as with ACM.
+func (r *Result) evaluateDependencies() {
+	e := dependencyEvaluator{
+		objects:    r.Objects,
+		templates:  map[string][]int{},
+		policies:   map[string][]string{},
+		names:      map[string][]string{},
+		states:     map[string]policyv1.ComplianceState{},
+		evaluating: map[string]bool{},
+	}
+
+	for i, o := range r.Objects {
+		if o.Policy == "" {
+			continue
+		}
+
+		key := o.Policy + "/" + o.Template
+		if _, found := e.templates[key]; !found {
+			e.policies[o.Policy] = append(e.policies[o.Policy], key)
+			e.names[o.Template] = append(e.names[o.Template], key)
+		}
+
+		e.templates[key] = append(e.templates[key], i)
+	}
+
+	if len(e.templates) == 0 {
+		return
+	}
+
+	for _, objects := range e.templates {
+		blockedBy := e.blockedBy(r.Objects[objects[0]].dependencies)
+		for _, i := range objects {
+			r.Objects[i].BlockedBy = blockedBy
+		}
+	}
+
+	depths := map[string]int{}
+	for policy := range e.policies {
+		e.depth(policy, depths, map[string]bool{})
+	}
+
+	sort.SliceStable(r.Objects, func(i, j int) bool {
+		return depths[r.Objects[i].Policy] < depths[r.Objects[j].Policy]
+	})
+}
+
+// blockedBy describes the dependencies that are not in the state they require.
+func (e dependencyEvaluator) blockedBy(dependencies []resource.Dependency) []string {
+	var blockedBy []string
+
+	for _, d := range dependencies {
+		if state := e.evaluateDependency(d); string(state) != d.Compliance {
+			blockedBy = append(blockedBy, fmt.Sprintf("%s is %s, requires %s", d, state, d.Compliance))
+		}
+	}
+
+	return blockedBy
+}
+
+// evaluateDependency returns the state of a Policy or template dependency. PolicySets are not
+// compared, so their state is unknown.
+func (e dependencyEvaluator) evaluateDependency(d resource.Dependency) policyv1.ComplianceState {
+	switch d.Kind {
+	case policyKind:
+		return e.combine(e.policies[d.Namespace+"/"+d.Name], true)
+	case policySetKind:
+		return unknownCompliance
+	default:
+		return e.combine(e.names[d.Name], false)
+	}
+}
+
+// dependencyPolicies returns the Policies of the result a Policy or template dependency refers to.
+func (e dependencyEvaluator) dependencyPolicies(d resource.Dependency) []string {
+	switch d.Kind {
+	case policyKind:
+		return []string{d.Namespace + "/" + d.Name}
+	case policySetKind:
+		return nil
+	default:
+		policies := make([]string, 0, len(e.names[d.Name]))
+		for _, key := range e.names[d.Name] {
+			policies = append(policies, e.objects[e.templates[key][0]].Policy)
+		}
+
+		return policies
+	}
+}
+
+// combine returns the state of the templates: NonCompliant when one is, Pending when one is,
+// unless ignorePending is set along with the IgnorePending of the template, Compliant otherwise.
+func (e dependencyEvaluator) combine(templates []string, ignorePending bool) policyv1.ComplianceState {
+	if len(templates) == 0 {
+		return unknownCompliance
+	}
+
+	state := policyv1.Compliant
+
+	for _, key := range templates {
+		switch e.evaluateTemplate(key) {
+		case policyv1.NonCompliant:
+			return policyv1.NonCompliant
+		case policyv1.Pending:
+			if !ignorePending || !e.objects[e.templates[key][0]].ignorePending {
+				state = policyv1.Pending
+			}
+		case policyv1.Compliant, unknownCompliance:
+		}
+	}
+
+	return state
+}
+
+func (e dependencyEvaluator) evaluateTemplate(key string) policyv1.ComplianceState {
+	if state, found := e.states[key]; found {
+		return state
+	}
+
+	if e.evaluating[key] {
+		return policyv1.Pending
+	}
+
+	e.evaluating[key] = true
+	defer delete(e.evaluating, key)
+
+	objects := e.templates[key]
+	state := policyv1.Compliant
+
+	if len(e.blockedBy(e.objects[objects[0]].dependencies)) > 0 {
+		state = policyv1.Pending
+	} else {
+		for _, i := range objects {
+			if !e.objects[i].Status.Passed() {
+				state = policyv1.NonCompliant
+
+				break
+			}
+		}
+	}
+
+	e.states[key] = state
+
+	return state
+}
+
+// depth is the length of the longest chain of Policies the Policy depends on.
+func (e dependencyEvaluator) depth(policy string, depths map[string]int, visiting map[string]bool) int {
+	if d, found := depths[policy]; found {
+		return d
+	}
+
+	if visiting[policy] {
+		return 0
+	}
+
+	visiting[policy] = true
+	depth := 0
+
+	for _, key := range e.policies[policy] {
+		for _, d := range e.objects[e.templates[key][0]].dependencies {
+			for _, p := range e.dependencyPolicies(d) {
+				if _, found := e.policies[p]; found {
+					depth = max(depth, e.depth(p, depths, visiting)+1)
+				}
+			}
+		}
+	}
+
+	depths[policy] = depth
+
+	return depth
+}
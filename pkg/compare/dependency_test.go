@@ -0,0 +1,123 @@
+package compare
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/openshift-kni/reference-validator/pkg/policy"
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+)
+
+func TestComparator_Compare_dependencies(t *testing.T) {
+	policyYAML := `
+apiVersion: policy.open-cluster-management.io/v1
+kind: Policy
+metadata:
+  name: config
+  namespace: ztp
+spec:
+  dependencies:
+  - apiVersion: policy.open-cluster-management.io/v1
+    kind: Policy
+    name: operators
+    compliance: Compliant
+  policy-templates:
+  - objectDefinition:
+      apiVersion: policy.open-cluster-management.io/v1
+      kind: ConfigurationPolicy
+      metadata:
+        name: config
+      spec:
+        object-templates:
+        - complianceType: musthave
+          objectDefinition:
+            apiVersion: v1
+            kind: ConfigMap
+            metadata:
+              name: a-config
+              namespace: ns1
+---
+apiVersion: policy.open-cluster-management.io/v1
+kind: Policy
+metadata:
+  name: operators
+  namespace: ztp
+spec:
+  policy-templates:
+  - objectDefinition:
+      apiVersion: policy.open-cluster-management.io/v1
+      kind: ConfigurationPolicy
+      metadata:
+        name: operators
+      spec:
+        object-templates:
+        - complianceType: musthave
+          objectDefinition:
+            apiVersion: v1
+            kind: ConfigMap
+            metadata:
+              name: z-operators
+              namespace: ns1
+`
+	resourceYAML := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a-config
+  namespace: ns1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: z-operators
+  namespace: ns1
+`
+
+	references, diags := resource.Decode([]byte(policyYAML), "policy.yaml")
+	references, policyDiags := policy.ExpandPolicies(references, policy.Options{})
+	diags = append(diags, policyDiags...)
+
+	if len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics %v", diags)
+	}
+
+	resources, _ := resource.Decode([]byte(resourceYAML), "resources.yaml")
+
+	type args struct {
+		resources []resource.Object
+	}
+
+	tests := []struct {
+		name          string
+		args          args
+		wantBlockedBy []string
+	}{
+		{
+			name: "templates are not blocked by compliant dependencies",
+			args: args{resources: resources},
+		},
+		{
+			name:          "templates are blocked by non-compliant dependencies",
+			args:          args{resources: resources[:1]},
+			wantBlockedBy: []string{"Policy ztp/operators is NonCompliant, requires Compliant"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rep := NewComparator(Config{}).Compare(references, tt.args.resources)
+
+			if len(rep.Objects) != 2 || rep.Objects[0].Name != "z-operators" || rep.Objects[1].Name != "a-config" {
+				t.Fatalf("Compare() = %+v, want the objects of the dependency first", rep.Objects)
+			}
+
+			if rep.Objects[1].Policy != "ztp/config" || rep.Objects[1].Template != "config" {
+				t.Errorf("Compare() policy = %s, template = %s", rep.Objects[1].Policy, rep.Objects[1].Template)
+			}
+
+			if !reflect.DeepEqual(rep.Objects[1].BlockedBy, tt.wantBlockedBy) {
+				t.Errorf("Compare() blocked by %v, want %v", rep.Objects[1].BlockedBy, tt.wantBlockedBy)
+			}
+		})
+	}
+}
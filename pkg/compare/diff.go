@@ -0,0 +1,290 @@
+package compare
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// DiffType tells on which side of a comparison a field differs.
+type DiffType string
+
+const (
+	// DiffAdded is a field present in the resource but not in the reference.
+	DiffAdded DiffType = "added"
+	// DiffRemoved is a field present in the reference but not in the resource.
+	DiffRemoved DiffType = "removed"
+	// DiffChanged is a field present on both sides with different values.
+	DiffChanged DiffType = "changed"
+)
+
+// FieldDiff is a single difference between a reference and a resource, located by its JSON path.
+type FieldDiff struct {
+	Path      string      `json:"path"`
+	Type      DiffType    `json:"type"`
+	Reference interface{} `json:"reference,omitempty"`
+	Resource  interface{} `json:"resource,omitempty"`
+	// Message explains why a resource value does not match a templated reference value.
+	Message string `json:"message,omitempty"`
+}
+
+func (d FieldDiff) String() string {
+	switch d.Type {
+	case DiffAdded:
+		return fmt.Sprintf("+ %s: %v", d.Path, d.Resource)
+	case DiffRemoved:
+		return fmt.Sprintf("- %s: %v", d.Path, d.Reference)
+	case DiffChanged:
+		if d.Message != "" {
+			return fmt.Sprintf("~ %s: %v -> %v (%s)", d.Path, d.Reference, d.Resource, d.Message)
+		}
+
+		return fmt.Sprintf("~ %s: %v -> %v", d.Path, d.Reference, d.Resource)
+	}
+
+	return d.Path
+}
+
+// MatchMode tells how a resource must match a reference without a compliance type.
+type MatchMode string
+
+const (
+	// MatchSubset passes when every field of the reference is present and equal in the resource.
+	MatchSubset MatchMode = "subset"
+	// MatchExact passes when both objects are identical.
+	MatchExact MatchMode = "exact"
+)
+
+// MatchModes lists the supported match modes.
+var MatchModes = []string{string(MatchSubset), string(MatchExact)}
+
+// diffObjectsWithMode returns the differences relevant to the match mode, sorted by path.
+func diffObjectsWithMode(reference, resource map[string]interface{}, mode MatchMode) []FieldDiff {
+	if mode == MatchExact {
+		return diffObjects(reference, resource)
+	}
+
+	diffs := diffSubset("", reference, resource)
+
+	sort.SliceStable(diffs, func(i, j int) bool {
+		return diffs[i].Path < diffs[j].Path
+	})
+
+	return diffs
+}
+
+// diffObjects walks both objects and returns every added, removed and changed field sorted by path.
+func diffObjects(reference, resource map[string]interface{}) []FieldDiff {
+	diffs := diffValues("", reference, resource)
+
+	sort.SliceStable(diffs, func(i, j int) bool {
+		return diffs[i].Path < diffs[j].Path
+	})
+
+	return diffs
+}
+
+func diffValues(path string, reference, resource interface{}) []FieldDiff {
+	switch ref := reference.(type) {
+	case string:
+		if IsTemplated(ref) {
+			return diffTemplate(path, ref, resource)
+		}
+	case map[string]interface{}:
+		if res, ok := resource.(map[string]interface{}); ok {
+			return diffMaps(path, ref, res)
+		}
+	case []interface{}:
+		if res, ok := resource.([]interface{}); ok {
+			return diffSlices(path, ref, res)
+		}
+	}
+
+	if reflect.DeepEqual(reference, resource) {
+		return nil
+	}
+
+	return []FieldDiff{{Path: path, Type: DiffChanged, Reference: reference, Resource: resource}}
+}
+
+func diffMaps(path string, reference, resource map[string]interface{}) []FieldDiff {
+	var diffs []FieldDiff
+
+	for key, refValue := range reference {
+		childPath := joinFieldPath(path, key)
+
+		resValue, exists := resource[key]
+		if !exists {
+			diffs = append(diffs, FieldDiff{Path: childPath, Type: DiffRemoved, Reference: refValue})
+
+			continue
+		}
+
+		diffs = append(diffs, diffValues(childPath, refValue, resValue)...)
+	}
+
+	for key, resValue := range resource {
+		if _, exists := reference[key]; !exists {
+			diffs = append(diffs, FieldDiff{Path: joinFieldPath(path, key), Type: DiffAdded, Resource: resValue})
+		}
+	}
+
+	return diffs
+}
+
+func diffSlices(path string, reference, resource []interface{}) []FieldDiff {
+	var diffs []FieldDiff
+
+	for i := 0; i < len(reference) || i < len(resource); i++ {
+		childPath := fmt.Sprintf("%s[%d]", path, i)
+
+		switch {
+		case i >= len(resource):
+			diffs = append(diffs, FieldDiff{Path: childPath, Type: DiffRemoved, Reference: reference[i]})
+		case i >= len(reference):
+			diffs = append(diffs, FieldDiff{Path: childPath, Type: DiffAdded, Resource: resource[i]})
+		default:
+			diffs = append(diffs, diffValues(childPath, reference[i], resource[i])...)
+		}
+	}
+
+	return diffs
+}
+
+// joinFieldPath appends a map key to a JSON path, quoting keys that are not plain identifiers
+// such as annotation and label names.
+func joinFieldPath(path, key string) string {
+	if key == "" || strings.ContainsAny(key, `.[]"/ `) {
+		return fmt.Sprintf("%s[%q]", path, key)
+	}
+
+	return path + "." + key
+}
+
+// diffSubset reports the fields of the reference that are missing or different in the resource,
+// allowing extra fields in the resource. Like the ConfigurationPolicy musthave compliance type,
+// every item of a reference list must match an item of the resource list, in any order.
+func diffSubset(path string, reference, resource interface{}) []FieldDiff {
+	switch ref := reference.(type) {
+	case string:
+		if IsTemplated(ref) {
+			return diffTemplate(path, ref, resource)
+		}
+	case map[string]interface{}:
+		res, ok := resource.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		var diffs []FieldDiff
+
+		for key, refValue := range ref {
+			childPath := joinFieldPath(path, key)
+
+			resValue, exists := res[key]
+			if !exists {
+				diffs = append(diffs, FieldDiff{Path: childPath, Type: DiffRemoved, Reference: refValue})
+
+				continue
+			}
+
+			diffs = append(diffs, diffSubset(childPath, refValue, resValue)...)
+		}
+
+		return diffs
+	case []interface{}:
+		res, ok := resource.([]interface{})
+		if !ok {
+			break
+		}
+
+		return diffSubsetSlices(path, ref, res)
+	}
+
+	if reflect.DeepEqual(reference, resource) {
+		return nil
+	}
+
+	return []FieldDiff{{Path: path, Type: DiffChanged, Reference: reference, Resource: resource}}
+}
+
+func diffSubsetSlices(path string, reference, resource []interface{}) []FieldDiff {
+	var diffs []FieldDiff
+
+	for i, refItem := range reference {
+		if containsSubset(resource, refItem) {
+			continue
+		}
+
+		childPath := fmt.Sprintf("%s[%d]", path, i)
+
+		// the item at the same index is the most likely counterpart, so detail what differs in it
+		if i < len(resource) {
+			diffs = append(diffs, diffSubset(childPath, refItem, resource[i])...)
+
+			continue
+		}
+
+		diffs = append(diffs, FieldDiff{Path: childPath, Type: DiffRemoved, Reference: refItem})
+	}
+
+	return diffs
+}
+
+// pruneSubset returns the fields of the resource value listed in the reference value, as
+// diffSubset checks them. Every item of a reference list keeps the resource item it matches, or
+// the one at the same index, which diffSubset details the differences of.
+func pruneSubset(reference, resource interface{}) interface{} {
+	switch ref := reference.(type) {
+	case map[string]interface{}:
+		res, ok := resource.(map[string]interface{})
+		if !ok {
+			return resource
+		}
+
+		pruned := make(map[string]interface{}, len(ref))
+
+		for key, refValue := range ref {
+			if resValue, exists := res[key]; exists {
+				pruned[key] = pruneSubset(refValue, resValue)
+			}
+		}
+
+		return pruned
+	case []interface{}:
+		res, ok := resource.([]interface{})
+		if !ok {
+			return resource
+		}
+
+		pruned := make([]interface{}, 0, len(ref))
+
+		for i, refItem := range ref {
+			if j := indexSubset(res, refItem); j >= 0 {
+				pruned = append(pruned, pruneSubset(refItem, res[j]))
+			} else if i < len(res) {
+				pruned = append(pruned, pruneSubset(refItem, res[i]))
+			}
+		}
+
+		return pruned
+	}
+
+	return resource
+}
+
+func containsSubset(items []interface{}, reference interface{}) bool {
+	return indexSubset(items, reference) >= 0
+}
+
+// indexSubset returns the index of the first item the reference is a subset of, or -1.
+func indexSubset(items []interface{}, reference interface{}) int {
+	for i, item := range items {
+		if len(diffSubset("", reference, item)) == 0 {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,141 @@
+package compare
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_diffObjects(t *testing.T) {
+	type args struct {
+		reference map[string]interface{}
+		resource  map[string]interface{}
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want []FieldDiff
+	}{
+		{
+			name: "identical objects have no differences",
+			args: args{
+				reference: map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(1)}},
+				resource:  map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(1)}},
+			},
+			want: nil,
+		},
+		{
+			name: "added, removed and changed fields are reported by path",
+			args: args{
+				reference: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"labels": map[string]interface{}{"app.kubernetes.io/name": "ref"},
+					},
+					"spec": map[string]interface{}{"a": "x", "b": "y"},
+				},
+				resource: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"labels": map[string]interface{}{"app.kubernetes.io/name": "res"},
+					},
+					"spec": map[string]interface{}{"a": "x", "c": "z"},
+				},
+			},
+			want: []FieldDiff{
+				{Path: `.metadata.labels["app.kubernetes.io/name"]`, Type: DiffChanged, Reference: "ref", Resource: "res"},
+				{Path: ".spec.b", Type: DiffRemoved, Reference: "y"},
+				{Path: ".spec.c", Type: DiffAdded, Resource: "z"},
+			},
+		},
+		{
+			name: "list items are compared by index",
+			args: args{
+				reference: map[string]interface{}{"items": []interface{}{"a", "b"}},
+				resource:  map[string]interface{}{"items": []interface{}{"a", "c", "d"}},
+			},
+			want: []FieldDiff{
+				{Path: ".items[1]", Type: DiffChanged, Reference: "b", Resource: "c"},
+				{Path: ".items[2]", Type: DiffAdded, Resource: "d"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffObjects(tt.args.reference, tt.args.resource); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffObjects() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_diffObjectsWithMode_subset(t *testing.T) {
+	type args struct {
+		reference map[string]interface{}
+		resource  map[string]interface{}
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want []FieldDiff
+	}{
+		{
+			name: "extra resource fields are allowed",
+			args: args{
+				reference: map[string]interface{}{"spec": map[string]interface{}{"a": "x"}},
+				resource:  map[string]interface{}{"spec": map[string]interface{}{"a": "x", "b": "y"}, "status": "ok"},
+			},
+			want: nil,
+		},
+		{
+			name: "missing and changed reference fields are reported",
+			args: args{
+				reference: map[string]interface{}{"spec": map[string]interface{}{"a": "x", "b": "y"}},
+				resource:  map[string]interface{}{"spec": map[string]interface{}{"a": "z"}},
+			},
+			want: []FieldDiff{
+				{Path: ".spec.a", Type: DiffChanged, Reference: "x", Resource: "z"},
+				{Path: ".spec.b", Type: DiffRemoved, Reference: "y"},
+			},
+		},
+		{
+			name: "list items match in any order and as subsets",
+			args: args{
+				reference: map[string]interface{}{"items": []interface{}{
+					map[string]interface{}{"name": "b"},
+					map[string]interface{}{"name": "a"},
+				}},
+				resource: map[string]interface{}{"items": []interface{}{
+					map[string]interface{}{"name": "a", "value": int64(1)},
+					map[string]interface{}{"name": "b", "value": int64(2)},
+					map[string]interface{}{"name": "c"},
+				}},
+			},
+			want: nil,
+		},
+		{
+			name: "unmatched list items are detailed against the item at the same index",
+			args: args{
+				reference: map[string]interface{}{"items": []interface{}{
+					map[string]interface{}{"name": "a", "value": int64(2)},
+					"extra",
+				}},
+				resource: map[string]interface{}{"items": []interface{}{
+					map[string]interface{}{"name": "a", "value": int64(1)},
+				}},
+			},
+			want: []FieldDiff{
+				{Path: ".items[0].value", Type: DiffChanged, Reference: int64(2), Resource: int64(1)},
+				{Path: ".items[1]", Type: DiffRemoved, Reference: "extra"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffObjectsWithMode(tt.args.reference, tt.args.resource, MatchSubset); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffObjectsWithMode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package compare_test
+
+import (
+	"fmt"
+
+	"github.com/openshift-kni/reference-validator/pkg/compare"
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+)
+
+func ExampleComparator_Compare() {
+	references, _ := resource.Decode([]byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: site
+data:
+  mode: performance
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: monitoring
+`), "reference.yaml")
+
+	resources, _ := resource.Decode([]byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: site
+  uid: 0b7a5c9e-2c8e-4a8f-9d4e-3f2b1a0c9d8e
+data:
+  mode: balanced
+  extra: allowed
+`), "cluster.yaml")
+
+	ignore, _ := compare.NewIgnoreList(true, nil, "")
+	comparator := compare.NewComparator(compare.Config{Ignore: ignore, Match: compare.MatchSubset})
+	result := comparator.Compare(references, resources)
+
+	for _, o := range result.Objects {
+		fmt.Println(o.Status, o.ID())
+
+		for _, d := range o.Differences {
+			fmt.Println(" ", d)
+		}
+	}
+
+	fmt.Println(result.Summary)
+	fmt.Println("passed:", result.Passed())
+
+	// Output:
+	// different v1/ConfigMap site/config
+	//   ~ .data.mode: performance -> balanced
+	// missing v1/Namespace monitoring
+	// Summary: 0 matched, 1 different, 1 missing, 0 unexpected
+	// passed: false
+}
+
+func ExampleNewIgnoreList() {
+	ignore, err := compare.NewIgnoreList(false, []string{
+		".spec.replicas",
+		`.metadata.annotations["deployment.kubernetes.io/revision"]`,
+		"/spec/template/spec/containers/*/terminationMessagePath",
+	}, "")
+	if err != nil {
+		fmt.Println(err)
+
+		return
+	}
+
+	fmt.Println(len(ignore), "ignored fields")
+
+	// Output:
+	// 3 ignored fields
+}
@@ -0,0 +1,217 @@
+package compare
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultIgnoredFields are populated by the API server and never part of a reference.
+var DefaultIgnoredFields = []string{
+	".metadata.uid",
+	".metadata.resourceVersion",
+	".metadata.creationTimestamp",
+	".metadata.managedFields",
+	".metadata.generation",
+	`.metadata.annotations["kubectl.kubernetes.io/last-applied-configuration"]`,
+	".status",
+}
+
+// ignoreFile is the format of the files given to NewIgnoreList.
+type ignoreFile struct {
+	// Global paths are ignored on every object.
+	Global []string `json:"global,omitempty"`
+	// Kinds paths are only ignored on objects of the given kind.
+	Kinds []ignoreKindRule `json:"kinds,omitempty"`
+}
+
+type ignoreKindRule struct {
+	// APIVersion restricts the rule to a single version of the kind when set.
+	APIVersion string   `json:"apiVersion,omitempty"`
+	Kind       string   `json:"kind"`
+	Paths      []string `json:"paths"`
+}
+
+// ignoreEntry is a parsed path, restricted to a kind when kind is set.
+type ignoreEntry struct {
+	apiVersion string
+	kind       string
+	path       []string
+}
+
+// IgnoreList holds the fields stripped from both sides before comparing them.
+type IgnoreList []ignoreEntry
+
+// NewIgnoreList builds the ignore list from DefaultIgnoredFields when withDefaults is set, the
+// global paths and the optional ignore file. Paths are either JSON paths such as
+// `.metadata.annotations["a/b"]` and `.spec.containers[*].image`, or JSONPointers.
+// The ignore file is YAML with a "global" list of paths and a "kinds" list of
+// {apiVersion, kind, paths} rules.
+func NewIgnoreList(withDefaults bool, globalPaths []string, file string) (IgnoreList, error) {
+	config := ignoreFile{Global: globalPaths}
+
+	if withDefaults {
+		config.Global = append(append([]string{}, DefaultIgnoredFields...), config.Global...)
+	}
+
+	if file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not read ignore file: %w", err)
+		}
+
+		fromFile := ignoreFile{}
+		if err := yaml.UnmarshalStrict(content, &fromFile); err != nil {
+			return nil, fmt.Errorf("invalid ignore file %s: %w", file, err)
+		}
+
+		config.Global = append(config.Global, fromFile.Global...)
+		config.Kinds = append(config.Kinds, fromFile.Kinds...)
+	}
+
+	var list IgnoreList
+
+	for _, p := range config.Global {
+		path, err := parseFieldPath(p)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, ignoreEntry{path: path})
+	}
+
+	for _, rule := range config.Kinds {
+		for _, p := range rule.Paths {
+			path, err := parseFieldPath(p)
+			if err != nil {
+				return nil, err
+			}
+
+			list = append(list, ignoreEntry{apiVersion: rule.APIVersion, kind: rule.Kind, path: path})
+		}
+	}
+
+	return list, nil
+}
+
+// strip returns a copy of the object without the ignored fields.
+func (l IgnoreList) strip(obj map[string]interface{}) map[string]interface{} {
+	stripped := runtime.DeepCopyJSON(obj)
+	apiVersion, _ := obj["apiVersion"].(string)
+	kind, _ := obj["kind"].(string)
+
+	for _, entry := range l {
+		if entry.kind != "" && entry.kind != kind {
+			continue
+		}
+
+		if entry.apiVersion != "" && entry.apiVersion != apiVersion {
+			continue
+		}
+
+		removeField(stripped, entry.path)
+	}
+
+	return stripped
+}
+
+// removeField deletes the value at path, where "*" matches every key or item. Maps left empty
+// by the removal are deleted as well so that they do not show up as differences.
+func removeField(value interface{}, path []string) {
+	if len(path) == 0 {
+		return
+	}
+
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, child := range typed {
+			if path[0] != "*" && path[0] != key {
+				continue
+			}
+
+			if len(path) == 1 {
+				delete(typed, key)
+
+				continue
+			}
+
+			removeField(child, path[1:])
+
+			if childMap, ok := child.(map[string]interface{}); ok && len(childMap) == 0 {
+				delete(typed, key)
+			}
+		}
+	case []interface{}:
+		if len(path) == 1 {
+			// removing list items would shift the others, so only their content can be ignored
+			return
+		}
+
+		for i, child := range typed {
+			if path[0] == "*" || path[0] == strconv.Itoa(i) {
+				removeField(child, path[1:])
+			}
+		}
+	}
+}
+
+// parseFieldPath splits a JSONPointer ("/metadata/labels/app") or a JSON path
+// (".metadata.labels.app", `.metadata.annotations["a/b"]`, ".spec.containers[*].image")
+// into its segments. List indexes are kept as their decimal representation.
+func parseFieldPath(expr string) ([]string, error) {
+	if strings.HasPrefix(expr, "/") {
+		segments := strings.Split(expr[1:], "/")
+		for i, s := range segments {
+			segments[i] = strings.ReplaceAll(strings.ReplaceAll(s, "~1", "/"), "~0", "~")
+		}
+
+		return segments, nil
+	}
+
+	var segments []string
+
+	rest := strings.TrimPrefix(expr, ".")
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, `["`):
+			end := strings.Index(rest, `"]`)
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: unterminated bracket", expr)
+			}
+
+			segment, err := strconv.Unquote(rest[1 : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid path %q: %w", expr, err)
+			}
+
+			segments = append(segments, segment)
+			rest = strings.TrimPrefix(rest[end+2:], ".")
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: unterminated bracket", expr)
+			}
+
+			segments = append(segments, rest[1:end])
+			rest = strings.TrimPrefix(rest[end+1:], ".")
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+
+			segments = append(segments, rest[:end])
+			rest = strings.TrimPrefix(rest[end:], ".")
+		}
+	}
+
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("invalid path %q: no field", expr)
+	}
+
+	return segments, nil
+}
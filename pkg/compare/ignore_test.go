@@ -0,0 +1,94 @@
+package compare
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseFieldPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "dotted path", expr: ".metadata.labels.app", want: []string{"metadata", "labels", "app"}},
+		{name: "dotted path without leading dot", expr: "spec.replicas", want: []string{"spec", "replicas"}},
+		{
+			name: "quoted key",
+			expr: `.metadata.annotations["kubectl.kubernetes.io/last-applied-configuration"]`,
+			want: []string{"metadata", "annotations", "kubectl.kubernetes.io/last-applied-configuration"},
+		},
+		{name: "list wildcard", expr: ".spec.containers[*].image", want: []string{"spec", "containers", "*", "image"}},
+		{name: "JSONPointer", expr: "/metadata/annotations/a~1b", want: []string{"metadata", "annotations", "a/b"}},
+		{name: "unterminated bracket", expr: ".spec[0", wantErr: true},
+		{name: "empty path", expr: ".", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFieldPath(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFieldPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFieldPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ignoreList_strip(t *testing.T) {
+	obj := map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name": "app",
+			"uid":  "1234",
+			"annotations": map[string]interface{}{
+				"kubectl.kubernetes.io/last-applied-configuration": "{}",
+			},
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "c", "terminationMessagePath": "/dev/termination-log"},
+					},
+				},
+			},
+		},
+		"status": map[string]interface{}{"replicas": int64(3)},
+	}
+
+	list, err := NewIgnoreList(true, []string{".spec.template.spec.containers[*].terminationMessagePath"}, "")
+	if err != nil {
+		t.Fatalf("NewIgnoreList() error = %v", err)
+	}
+
+	list = append(list, ignoreEntry{kind: "Deployment", path: []string{"spec", "replicas"}})
+	list = append(list, ignoreEntry{kind: "StatefulSet", path: []string{"metadata", "name"}})
+
+	want := map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "app"},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{"name": "c"}},
+				},
+			},
+		},
+	}
+
+	if got := list.strip(obj); !reflect.DeepEqual(got, want) {
+		t.Errorf("strip() = %v, want %v", got, want)
+	}
+
+	if _, exists := obj["status"]; !exists {
+		t.Errorf("strip() modified its input")
+	}
+}
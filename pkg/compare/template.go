@@ -0,0 +1,341 @@
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A reference value may contain Go-template-style placeholders such as "{{ .spec.cpu.isolated }}"
+// which capture any resource value, so that a single reference covers every site. The captured
+// value can be constrained with a pipeline of functions:
+//
+//	{{ .spec.cpu.isolated | regex "^[0-9,-]+$" }}  the value must match the regular expression
+//	{{ .spec.profile | enum "low" "high" }}        the value must be one of the arguments
+//	{{ .spec.replicas | range 1 3 }}               the value must be a number within the bounds, _ leaves one open
+//
+// A value made of a single placeholder captures values of any type, whereas placeholders mixed
+// with text, such as "worker-{{ .name }}", only match strings.
+
+var (
+	errUnterminatedPlaceholder = errors.New("unterminated placeholder")
+	errUnterminatedQuote       = errors.New("unterminated quote")
+)
+
+// placeholderStart finds the placeholders of a value, leaving aside other templates such as hub
+// templates.
+var placeholderStart = regexp.MustCompile(`\{\{\s*\.`)
+
+// IsTemplated is true for reference values holding at least one placeholder.
+func IsTemplated(value string) bool {
+	return placeholderStart.MatchString(value)
+}
+
+// constraint checks a captured value and returns why it is rejected, or an empty string.
+type constraint func(value interface{}) string
+
+// placeholder is a single "{{ ... }}" of a reference value.
+type placeholder struct {
+	// Field documents what the placeholder captures, such as .spec.cpu.isolated.
+	Field       string
+	constraints []constraint
+}
+
+// valueTemplate is a parsed templated reference value.
+type valueTemplate struct {
+	placeholders []placeholder
+	// pattern matches string values, with a group per placeholder. It is nil when the value is a
+	// single placeholder.
+	pattern *regexp.Regexp
+}
+
+// parseValueTemplate parses a reference value holding placeholders.
+func parseValueTemplate(value string) (valueTemplate, error) {
+	tmpl := valueTemplate{}
+	pattern := strings.Builder{}
+	rest := value
+
+	pattern.WriteString(`(?s)^`)
+
+	for {
+		loc := placeholderStart.FindStringIndex(rest)
+		if loc == nil {
+			break
+		}
+
+		pattern.WriteString(regexp.QuoteMeta(rest[:loc[0]]))
+
+		tokens, length, err := tokenizePlaceholder(rest[loc[0]:])
+		if err != nil {
+			return valueTemplate{}, err
+		}
+
+		p, err := newPlaceholder(tokens)
+		if err != nil {
+			return valueTemplate{}, fmt.Errorf("invalid placeholder %s: %w", rest[loc[0]:loc[0]+length], err)
+		}
+
+		if loc[0] == 0 && length == len(value) {
+			// the whole value is a single placeholder
+			tmpl.placeholders = []placeholder{p}
+
+			return tmpl, nil
+		}
+
+		tmpl.placeholders = append(tmpl.placeholders, p)
+		pattern.WriteString(`(.*?)`)
+		rest = rest[loc[0]+length:]
+	}
+
+	pattern.WriteString(regexp.QuoteMeta(rest) + `$`)
+
+	compiled, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return valueTemplate{}, fmt.Errorf("could not compile %q: %w", value, err)
+	}
+
+	tmpl.pattern = compiled
+
+	return tmpl, nil
+}
+
+// tokenizePlaceholder splits the content of the placeholder starting value into whitespace
+// separated tokens, honoring double-quoted and backquoted strings, and returns the length of the
+// placeholder including its delimiters. Pipes are returned as "|" tokens.
+func tokenizePlaceholder(value string) ([]string, int, error) {
+	var (
+		tokens  []string
+		current strings.Builder
+		quoted  bool
+	)
+
+	flush := func() {
+		if current.Len() > 0 || quoted {
+			tokens = append(tokens, current.String())
+		}
+
+		current.Reset()
+
+		quoted = false
+	}
+
+	for i := len("{{"); i < len(value); i++ {
+		switch char := value[i]; {
+		case strings.HasPrefix(value[i:], "}}"):
+			flush()
+
+			return tokens, i + len("}}"), nil
+		case char == '"' || char == '`':
+			end := strings.IndexByte(value[i+1:], char)
+			if end < 0 {
+				return nil, 0, errUnterminatedQuote
+			}
+
+			literal := value[i : i+end+2]
+			if char == '"' {
+				unquoted, err := strconv.Unquote(literal)
+				if err != nil {
+					return nil, 0, fmt.Errorf("invalid string %s: %w", literal, err)
+				}
+
+				literal = unquoted
+			} else {
+				literal = literal[1 : len(literal)-1]
+			}
+
+			current.WriteString(literal)
+
+			quoted = true
+			i += end + 1
+		case char == '|':
+			flush()
+
+			tokens = append(tokens, "|")
+		case char == ' ' || char == '\t' || char == '\n':
+			flush()
+		default:
+			current.WriteByte(char)
+		}
+	}
+
+	return nil, 0, errUnterminatedPlaceholder
+}
+
+func newPlaceholder(tokens []string) (placeholder, error) {
+	if len(tokens) == 0 || !strings.HasPrefix(tokens[0], ".") {
+		return placeholder{}, errors.New("must start with a field such as .spec.name")
+	}
+
+	p := placeholder{Field: tokens[0]}
+	tokens = tokens[1:]
+
+	for len(tokens) > 0 {
+		if tokens[0] != "|" || len(tokens) < 2 {
+			return placeholder{}, fmt.Errorf("expected a function after %s", p.Field)
+		}
+
+		end := 1
+		for end < len(tokens) && tokens[end] != "|" {
+			end++
+		}
+
+		c, err := newConstraint(tokens[1], tokens[2:end])
+		if err != nil {
+			return placeholder{}, err
+		}
+
+		p.constraints = append(p.constraints, c)
+		tokens = tokens[end:]
+	}
+
+	return p, nil
+}
+
+func newConstraint(function string, args []string) (constraint, error) {
+	switch function {
+	case "regex":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("regex takes 1 argument, got %d", len(args))
+		}
+
+		re, err := regexp.Compile(`^(?:` + args[0] + `)$`)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex: %w", err)
+		}
+
+		return func(value interface{}) string {
+			if !re.MatchString(scalarString(value)) {
+				return fmt.Sprintf("does not match regex %q", args[0])
+			}
+
+			return ""
+		}, nil
+	case "enum":
+		if len(args) == 0 {
+			return nil, errors.New("enum takes at least 1 argument")
+		}
+
+		return func(value interface{}) string {
+			for _, allowed := range args {
+				if scalarString(value) == allowed {
+					return ""
+				}
+			}
+
+			return fmt.Sprintf("is not one of %s", strings.Join(args, ", "))
+		}, nil
+	case "range":
+		return newRangeConstraint(args)
+	}
+
+	return nil, fmt.Errorf("unknown function %q, must be one of: regex, enum, range", function)
+}
+
+func newRangeConstraint(args []string) (constraint, error) {
+	const bounds = 2
+
+	if len(args) != bounds {
+		return nil, fmt.Errorf("range takes 2 arguments, got %d", len(args))
+	}
+
+	limits := make([]*float64, bounds)
+
+	for i, arg := range args {
+		if arg == "_" {
+			continue
+		}
+
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range bound %q: %w", arg, err)
+		}
+
+		limits[i] = &limit
+	}
+
+	return func(value interface{}) string {
+		number, err := strconv.ParseFloat(scalarString(value), 64)
+		if err != nil {
+			return "is not a number"
+		}
+
+		if (limits[0] != nil && number < *limits[0]) || (limits[1] != nil && number > *limits[1]) {
+			return fmt.Sprintf("is not within range %s..%s", args[0], args[1])
+		}
+
+		return ""
+	}, nil
+}
+
+// scalarString formats the values constraints are checked against.
+func scalarString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+
+	return fmt.Sprint(value)
+}
+
+// match checks a resource value against the template, and returns why it does not match, or an
+// empty string.
+func (t valueTemplate) match(value interface{}) string {
+	if t.pattern == nil {
+		return t.placeholders[0].check(value)
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return "is not a string"
+	}
+
+	captures := t.pattern.FindStringSubmatch(s)
+	if captures == nil {
+		return "does not match the template"
+	}
+
+	for i, p := range t.placeholders {
+		if reason := p.check(captures[i+1]); reason != "" {
+			return reason
+		}
+	}
+
+	return ""
+}
+
+func (p placeholder) check(value interface{}) string {
+	for _, c := range p.constraints {
+		if reason := c(value); reason != "" {
+			return fmt.Sprintf("%s %s", p.Field, reason)
+		}
+	}
+
+	return ""
+}
+
+// matchesTemplate is true when the value is equal to the reference value or, for templated
+// references, matches the template.
+func matchesTemplate(reference, value string) bool {
+	if !IsTemplated(reference) {
+		return reference == value
+	}
+
+	tmpl, err := parseValueTemplate(reference)
+
+	return err == nil && tmpl.match(value) == ""
+}
+
+// diffTemplate reports the resource value at path when it does not match the templated reference.
+func diffTemplate(path, reference string, resource interface{}) []FieldDiff {
+	tmpl, err := parseValueTemplate(reference)
+	if err != nil {
+		return []FieldDiff{{Path: path, Type: DiffChanged, Reference: reference, Resource: resource, Message: err.Error()}}
+	}
+
+	if reason := tmpl.match(resource); reason != "" {
+		return []FieldDiff{{Path: path, Type: DiffChanged, Reference: reference, Resource: resource, Message: reason}}
+	}
+
+	return nil
+}
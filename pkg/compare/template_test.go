@@ -0,0 +1,104 @@
+package compare
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_diffTemplate(t *testing.T) {
+	type args struct {
+		reference string
+		resource  interface{}
+	}
+
+	tests := []struct {
+		name        string
+		args        args
+		wantMessage string
+	}{
+		{
+			name: "a single placeholder captures any value",
+			args: args{reference: "{{ .spec.cpu }}", resource: map[string]interface{}{"isolated": "2-5"}},
+		},
+		{
+			name: "regex constraint",
+			args: args{reference: `{{ .spec.cpu.isolated | regex "[0-9,-]+" }}`, resource: "2-5,7"},
+		},
+		{
+			name:        "regex constraint must match the whole value",
+			args:        args{reference: `{{ .spec.cpu.isolated | regex "[0-9,-]+" }}`, resource: "2-5,a"},
+			wantMessage: `.spec.cpu.isolated does not match regex "[0-9,-]+"`,
+		},
+		{
+			name:        "enum constraint",
+			args:        args{reference: `{{ .spec.profile | enum low "high" }}`, resource: "medium"},
+			wantMessage: ".spec.profile is not one of low, high",
+		},
+		{
+			name: "range constraint with an open bound",
+			args: args{reference: "{{ .spec.replicas | range 1 _ }}", resource: int64(12)},
+		},
+		{
+			name:        "range constraint",
+			args:        args{reference: "{{ .spec.replicas | range 1 3 }}", resource: int64(4)},
+			wantMessage: ".spec.replicas is not within range 1..3",
+		},
+		{
+			name: "placeholders mixed with text",
+			args: args{reference: `worker-{{ .site | regex "[a-z]+" }}.{{ .domain }}`, resource: "worker-east.example.com"},
+		},
+		{
+			name:        "text around placeholders must match",
+			args:        args{reference: "worker-{{ .site }}", resource: "master-east"},
+			wantMessage: "does not match the template",
+		},
+		{
+			name:        "quoted pipes and delimiters are part of the argument",
+			args:        args{reference: "{{ .mode | regex `a|}}` }}", resource: "b"},
+			wantMessage: ".mode does not match regex \"a|}}\"",
+		},
+		{
+			name:        "unknown function",
+			args:        args{reference: "{{ .mode | lower }}", resource: "b"},
+			wantMessage: `invalid placeholder {{ .mode | lower }}: unknown function "lower", must be one of: regex, enum, range`,
+		},
+		{
+			name:        "unterminated placeholder",
+			args:        args{reference: "{{ .mode", resource: "b"},
+			wantMessage: "unterminated placeholder",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var want []FieldDiff
+			if tt.wantMessage != "" {
+				want = []FieldDiff{{Path: ".x", Type: DiffChanged, Reference: tt.args.reference, Resource: tt.args.resource, Message: tt.wantMessage}}
+			}
+
+			if got := diffTemplate(".x", tt.args.reference, tt.args.resource); !reflect.DeepEqual(got, want) {
+				t.Errorf("diffTemplate() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func Test_isTemplated(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{value: "{{ .metadata.name }}", want: true},
+		{value: "site-{{.name}}", want: true},
+		{value: `{{hub fromConfigMap "" "site" "name" hub}}`, want: false},
+		{value: "$mcp", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := IsTemplated(tt.value); got != tt.want {
+				t.Errorf("IsTemplated() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
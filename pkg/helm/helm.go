@@ -0,0 +1,147 @@
+// Package helm renders local Helm charts offline, as helm template would without a cluster, so
+// that the manifests of charts can be compared.
+package helm
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+)
+
+const (
+	notesTemplate = "NOTES.txt"
+
+	// kubeVersion is the version of the vendored client, which helm template also reports when it
+	// does not reach a cluster.
+	kubeVersion = "v1.28.0"
+)
+
+// Options are the release a chart is rendered for.
+type Options struct {
+	ReleaseName string
+	Namespace   string
+	// ValuesFiles override the values of the chart, the last file taking precedence like with
+	// helm template --values.
+	ValuesFiles []string
+}
+
+// RenderDirs renders every chart directory, see Render.
+func RenderDirs(dirs []string, options Options) ([]resource.Object, []resource.Diagnostic) {
+	var (
+		objects []resource.Object
+		diags   []resource.Diagnostic
+	)
+
+	for _, dir := range dirs {
+		rendered, renderDiags, err := Render(dir, options)
+		if err != nil {
+			diags = append(diags, resource.Diagnostic{File: dir, Document: -1, Reason: err.Error()})
+
+			continue
+		}
+
+		objects = append(objects, rendered...)
+		diags = append(diags, renderDiags...)
+	}
+
+	return objects, diags
+}
+
+// Render renders the chart of dir, along with its enabled subcharts, with the Helm template engine
+// and its values, overridden by the values files of the options, and decodes the manifests the
+// templates output. The source of every object is its template. As with helm template, a template
+// that fails to render fails the chart, and lookup finds no object since there is no cluster.
+func Render(dir string, options Options) ([]resource.Object, []resource.Diagnostic, error) {
+	chart, err := loader.Load(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not read chart: %w", err)
+	}
+
+	overrides := map[string]interface{}{}
+
+	for _, file := range options.ValuesFiles {
+		fileValues, err := chartutil.ReadValuesFile(file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not read values: %w", err)
+		}
+
+		overrides = mergeValues(overrides, fileValues)
+	}
+
+	if err := chartutil.ProcessDependencies(chart, overrides); err != nil {
+		return nil, nil, fmt.Errorf("could not read dependencies of chart %s: %w", chart.Name(), err)
+	}
+
+	caps := chartutil.DefaultCapabilities.Copy()
+	caps.KubeVersion = chartutil.KubeVersion{Version: kubeVersion, Major: "1", Minor: "28"}
+
+	values, err := chartutil.ToRenderValues(chart, overrides, chartutil.ReleaseOptions{
+		Name: options.ReleaseName, Namespace: options.Namespace, Revision: 1, IsInstall: true,
+	}, caps)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid values of chart %s: %w", chart.Name(), err)
+	}
+
+	rendered, err := engine.Render(chart, values)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not render chart %s: %w", chart.Name(), err)
+	}
+
+	var (
+		objects []resource.Object
+		diags   []resource.Diagnostic
+	)
+
+	for _, name := range sortedKeys(rendered) {
+		if path.Base(name) == notesTemplate {
+			continue
+		}
+
+		// templates are named after the path of their file in the chart, prefixed by its name
+		file := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(name, chart.Name()+"/")))
+
+		templateObjects, templateDiags := resource.Decode([]byte(rendered[name]), file)
+		objects = append(objects, templateObjects...)
+		diags = append(diags, templateDiags...)
+	}
+
+	return objects, diags, nil
+}
+
+// mergeValues merges the overrides into the values, as helm template --values does: maps are
+// merged recursively and any other override replaces the value. A null override is kept, so that
+// it deletes the value of the chart.
+func mergeValues(values, overrides map[string]interface{}) map[string]interface{} {
+	for k, override := range overrides {
+		valueMap, isMap := values[k].(map[string]interface{})
+		overrideMap, overrideIsMap := override.(map[string]interface{})
+
+		if isMap && overrideIsMap {
+			values[k] = mergeValues(valueMap, overrideMap)
+
+			continue
+		}
+
+		values[k] = override
+	}
+
+	return values
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
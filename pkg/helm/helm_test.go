@@ -0,0 +1,211 @@
+package helm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func mustWriteTestFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestRender(t *testing.T) {
+	chart := map[string]string{
+		"Chart.yaml": `
+apiVersion: v2
+name: operator
+version: 1.2.0
+appVersion: "4.14"
+`,
+		"values.yaml": `
+replicas: 1
+image:
+  repository: quay.io/example/operator
+  tag: ""
+monitoring:
+  enabled: false
+`,
+		"templates/_helpers.tpl": `
+{{- define "operator.labels" -}}
+app.kubernetes.io/name: {{ .Chart.Name }}
+app.kubernetes.io/instance: {{ .Release.Name }}
+{{- end -}}
+`,
+		"templates/deployment.yaml": `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}-operator
+  namespace: {{ .Release.Namespace }}
+  labels:
+    {{- include "operator.labels" . | nindent 4 }}
+spec:
+  replicas: {{ .Values.replicas }}
+  template:
+    spec:
+      containers:
+      - name: operator
+        image: {{ .Values.image.repository }}:{{ .Values.image.tag | default .Chart.AppVersion }}
+`,
+		"templates/servicemonitor.yaml": `
+{{- if and .Values.monitoring.enabled (.Capabilities.APIVersions.Has "monitoring.coreos.com/v1") }}
+apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: {{ .Release.Name }}
+{{- end }}
+`,
+		"templates/NOTES.txt": "Installed {{ .Release.Name }}",
+	}
+	dir := mustWriteTestFiles(t, chart)
+	values := mustWriteTestFiles(t, map[string]string{"site.yaml": `
+replicas: 3
+monitoring:
+  enabled: true
+`})
+
+	type args struct {
+		options Options
+	}
+
+	tests := []struct {
+		name         string
+		args         args
+		wantReplicas int64
+		wantImage    string
+	}{
+		{
+			name:         "templates are rendered with the values of the chart",
+			args:         args{options: Options{ReleaseName: "ran", Namespace: "operators"}},
+			wantReplicas: 1,
+			wantImage:    "quay.io/example/operator:4.14",
+		},
+		{
+			name: "values files override the values of the chart",
+			args: args{options: Options{
+				ReleaseName: "ran", Namespace: "operators", ValuesFiles: []string{filepath.Join(values, "site.yaml")},
+			}},
+			wantReplicas: 3,
+			wantImage:    "quay.io/example/operator:4.14",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, diags, err := Render(dir, tt.args.options)
+			if err != nil || len(diags) != 0 {
+				t.Fatalf("Render() error = %v, diagnostics = %v", err, diags)
+			}
+
+			if len(got) != 1 {
+				t.Fatalf("Render() = %d objects, want the Deployment only", len(got))
+			}
+
+			deployment := got[0]
+			if deployment.GetName() != "ran-operator" || deployment.GetNamespace() != "operators" ||
+				deployment.GetLabels()["app.kubernetes.io/name"] != "operator" {
+				t.Errorf("Render() metadata = %v", deployment.Object["metadata"])
+			}
+
+			if deployment.Source.File != filepath.Join(dir, "templates", "deployment.yaml") {
+				t.Errorf("Render() source = %s", deployment.Source.File)
+			}
+
+			replicas, _, _ := unstructured.NestedInt64(deployment.Object, "spec", "replicas")
+			if replicas != tt.wantReplicas {
+				t.Errorf("Render() replicas = %d, want %d", replicas, tt.wantReplicas)
+			}
+
+			containers, _, _ := unstructured.NestedSlice(deployment.Object, "spec", "template", "spec", "containers")
+			container, _ := containers[0].(map[string]interface{})
+
+			if image, _, _ := unstructured.NestedString(container, "image"); image != tt.wantImage {
+				t.Errorf("Render() image = %s, want %s", image, tt.wantImage)
+			}
+		})
+	}
+}
+
+func TestRender_chartFunctions(t *testing.T) {
+	dir := mustWriteTestFiles(t, map[string]string{
+		"Chart.yaml": "apiVersion: v2\nname: operator\nversion: 1.0.0\n",
+		"templates/ingress.yaml": `
+{{- if semverCompare ">=1.19-0" .Capabilities.KubeVersion.GitVersion -}}
+apiVersion: networking.k8s.io/v1
+{{- else -}}
+apiVersion: networking.k8s.io/v1beta1
+{{- end }}
+kind: Ingress
+metadata:
+  name: {{ .Release.Name }}
+`,
+		"templates/configmap.yaml": `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Release.Name }}-config
+data:
+  {{- (.Files.Glob "files/*.conf").AsConfig | nindent 2 }}
+`,
+		"files/operator.conf":                 "debug = false",
+		"charts/agent/Chart.yaml":             "apiVersion: v2\nname: agent\nversion: 1.0.0\n",
+		"charts/agent/templates/account.yaml": "apiVersion: v1\nkind: ServiceAccount\nmetadata:\n  name: agent\n",
+	})
+
+	got, diags, err := Render(dir, Options{ReleaseName: "ran"})
+	if err != nil || len(diags) != 0 {
+		t.Fatalf("Render() error = %v, diagnostics = %v", err, diags)
+	}
+
+	objects := map[string]unstructured.Unstructured{}
+	for _, o := range got {
+		objects[o.GetKind()] = o.Unstructured
+	}
+
+	if ingress := objects["Ingress"]; ingress.GetAPIVersion() != "networking.k8s.io/v1" {
+		t.Errorf("Render() Ingress apiVersion = %s, want networking.k8s.io/v1", ingress.GetAPIVersion())
+	}
+
+	if data, _, _ := unstructured.NestedString(objects["ConfigMap"].Object, "data", "operator.conf"); data != "debug = false" {
+		t.Errorf("Render() ConfigMap data = %v", objects["ConfigMap"].Object["data"])
+	}
+
+	if _, found := objects["ServiceAccount"]; !found {
+		t.Errorf("Render() = %v, want the ServiceAccount of the subchart", got)
+	}
+}
+
+func TestRender_errors(t *testing.T) {
+	dir := mustWriteTestFiles(t, map[string]string{
+		"Chart.yaml":             "name: operator\nversion: 1.0.0\n",
+		"templates/secret.yaml":  `{{ required "a password is required" .Values.password }}`,
+		"templates/unused.yaml":  "",
+		"templates/_helpers.tpl": "",
+	})
+
+	if _, _, err := Render(dir, Options{}); err == nil {
+		t.Errorf("Render() succeeded, want the required value reported")
+	}
+
+	if _, _, err := Render(t.TempDir(), Options{}); err == nil {
+		t.Errorf("Render() of a directory without chart succeeded")
+	}
+}
@@ -0,0 +1,288 @@
+// Package kustomize builds kustomization directories in-process, so that the objects of kustomize
+// overlays can be compared rather than their raw base files.
+package kustomize
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/loader"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/yaml"
+)
+
+var errNotLocal = errors.New("remote or missing kustomization entry")
+
+// BuildDirs builds every kustomization directory, see Build.
+func BuildDirs(dirs []string) ([]resource.Object, []resource.Diagnostic) {
+	var (
+		objects []resource.Object
+		diags   []resource.Diagnostic
+	)
+
+	for _, dir := range dirs {
+		built, err := Build(dir)
+		if err != nil {
+			diags = append(diags, resource.Diagnostic{File: dir, Document: -1, Reason: err.Error()})
+
+			continue
+		}
+
+		objects = append(objects, built...)
+	}
+
+	return objects, diags
+}
+
+// Build renders the kustomization of dir without an external binary, plugins or network access:
+// kustomizations referring to remote bases or files are rejected. The source of every object is the file
+// of the base it was declared in, or the kustomization of the generator that created it.
+func Build(dir string) ([]resource.Object, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve %s: %w", dir, err)
+	}
+
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return nil, fmt.Errorf("could not resolve %s: %w", dir, err)
+	}
+
+	if err := checkLocal(root, map[string]bool{}); err != nil {
+		return nil, err
+	}
+
+	fSys := &originFileSystem{FileSystem: filesys.MakeFsOnDisk(), root: root}
+
+	resMap, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(fSys, root)
+	if err != nil {
+		return nil, fmt.Errorf("could not build kustomization %s: %w", dir, err)
+	}
+
+	objects := make([]resource.Object, 0, resMap.Size())
+
+	for _, r := range resMap.Resources() {
+		src := resource.Source{File: dir}
+
+		if origin, err := r.GetOrigin(); err == nil && origin != nil {
+			if origin.Path != "" {
+				src.File = filepath.Join(dir, origin.Path)
+			} else if origin.ConfiguredIn != "" {
+				src.File = filepath.Join(dir, origin.ConfiguredIn)
+			}
+		}
+
+		if fSys.injected {
+			annotations := r.GetAnnotations()
+			delete(annotations, "config.kubernetes.io/origin")
+
+			if err := r.SetAnnotations(annotations); err != nil {
+				return nil, fmt.Errorf("could not clean up origin of %s: %w", r.CurId(), err)
+			}
+		}
+
+		// JSON decoding gives the int64 numbers of Unstructured
+		data, err := r.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("could not convert %s: %w", r.CurId(), err)
+		}
+
+		content := map[string]interface{}{}
+		if err := utiljson.Unmarshal(data, &content); err != nil {
+			return nil, fmt.Errorf("could not convert %s: %w", r.CurId(), err)
+		}
+
+		objects = append(objects, resource.Object{Unstructured: unstructured.Unstructured{Object: content}, Source: src})
+	}
+
+	return objects, nil
+}
+
+// readKustomization returns the kustomization of dir, and false when dir has none.
+func readKustomization(dir string) (types.Kustomization, bool, error) {
+	for _, name := range konfig.RecognizedKustomizationFileNames() {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return types.Kustomization{}, false, fmt.Errorf("could not read kustomization: %w", err)
+		}
+
+		k := types.Kustomization{}
+		if err := yaml.Unmarshal(data, &k); err != nil {
+			return types.Kustomization{}, false, fmt.Errorf("invalid kustomization %s: %w", filepath.Join(dir, name), err)
+		}
+
+		return k, true, nil
+	}
+
+	return types.Kustomization{}, false, nil
+}
+
+// checkLocal returns an error for the entries of the kustomization of dir, and of the
+// kustomizations it refers to, that kustomize would fetch over the network: resources, bases and
+// components that are not local files or directories, which it would clone, and files of patches,
+// generators, replacements, CRDs and OpenAPI schemas that are not local files, which it would
+// download. Entries that may hold inline YAML are only rejected when they are URLs.
+func checkLocal(dir string, checked map[string]bool) error {
+	if checked[dir] {
+		return nil
+	}
+
+	checked[dir] = true
+
+	k, found, err := readKustomization(dir)
+	if err != nil || !found {
+		return err
+	}
+
+	entries := append(append(append([]string{}, k.Resources...), k.Bases...), k.Components...)
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry)
+
+		fileInfo, err := os.Stat(path)
+		if err != nil || loader.IsRemoteFile(entry) {
+			return fmt.Errorf("%w %s in %s", errNotLocal, entry, dir)
+		}
+
+		if fileInfo.IsDir() {
+			if err := checkLocal(path, checked); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, file := range localFiles(k) {
+		if fileInfo, err := os.Stat(filepath.Join(dir, file)); err != nil || fileInfo.IsDir() || loader.IsRemoteFile(file) {
+			return fmt.Errorf("%w %s in %s", errNotLocal, file, dir)
+		}
+	}
+
+	inline := append(append(append([]string{}, k.Generators...), k.Transformers...), k.Validators...)
+	for _, patch := range k.PatchesStrategicMerge {
+		inline = append(inline, string(patch))
+	}
+
+	for _, entry := range inline {
+		if loader.IsRemoteFile(entry) {
+			return fmt.Errorf("%w %s in %s", errNotLocal, entry, dir)
+		}
+	}
+
+	return nil
+}
+
+// localFiles returns the files the kustomization reads besides its resources, bases and
+// components, which must all be files.
+func localFiles(k types.Kustomization) []string {
+	files := append(append([]string{}, k.Crds...), k.Configurations...)
+
+	for _, patch := range append(append([]types.Patch{}, k.Patches...), k.PatchesJson6902...) {
+		if patch.Path != "" {
+			files = append(files, patch.Path)
+		}
+	}
+
+	for _, replacement := range k.Replacements {
+		if replacement.Path != "" {
+			files = append(files, replacement.Path)
+		}
+	}
+
+	if path := k.OpenAPI["path"]; path != "" {
+		files = append(files, path)
+	}
+
+	sources := []types.KvPairSources{}
+	for _, generator := range k.ConfigMapGenerator {
+		sources = append(sources, generator.KvPairSources)
+	}
+
+	for _, generator := range k.SecretGenerator {
+		sources = append(sources, generator.KvPairSources)
+	}
+
+	for _, source := range sources {
+		for _, file := range source.FileSources {
+			// files are listed as [key=]path
+			if key, path, found := strings.Cut(file, "="); found && !strings.ContainsAny(key, "/:") {
+				file = path
+			}
+
+			files = append(files, file)
+		}
+
+		files = append(files, source.EnvSources...)
+
+		if source.EnvSource != "" {
+			files = append(files, source.EnvSource)
+		}
+	}
+
+	return files
+}
+
+// originFileSystem enables the origin annotations of the root kustomization, which record the
+// file every object is read from.
+type originFileSystem struct {
+	filesys.FileSystem
+	root string
+	// injected is set when the kustomization did not ask for the origin annotations itself, so
+	// that they are removed from the objects.
+	injected bool
+}
+
+func (fs *originFileSystem) ReadFile(path string) ([]byte, error) {
+	data, err := fs.FileSystem.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	if filepath.Dir(path) != fs.root || !isKustomizationFile(path) {
+		return data, nil
+	}
+
+	k := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &k); err != nil {
+		// kustomize reports the invalid kustomization
+		return data, nil
+	}
+
+	buildMetadata, _ := k["buildMetadata"].([]interface{})
+	for _, option := range buildMetadata {
+		if option == types.OriginAnnotations {
+			return data, nil
+		}
+	}
+
+	k["buildMetadata"] = append(buildMetadata, types.OriginAnnotations)
+	fs.injected = true
+
+	data, err = yaml.Marshal(k)
+	if err != nil {
+		return nil, fmt.Errorf("could not enable origin annotations: %w", err)
+	}
+
+	return data, nil
+}
+
+func isKustomizationFile(path string) bool {
+	for _, name := range konfig.RecognizedKustomizationFileNames() {
+		if filepath.Base(path) == name {
+			return true
+		}
+	}
+
+	return false
+}
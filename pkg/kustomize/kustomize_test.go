@@ -0,0 +1,150 @@
+package kustomize
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func mustWriteTestFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestBuild(t *testing.T) {
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requested = true
+	}))
+	defer server.Close()
+
+	repo := mustWriteTestFiles(t, map[string]string{
+		"base/kustomization.yaml": `
+resources:
+- configmap.yaml
+`,
+		"base/configmap.yaml": `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  replicas: "1"
+`,
+		"overlay/kustomization.yaml": `
+namespace: site
+resources:
+- ../base
+patches:
+- path: replicas.yaml
+configMapGenerator:
+- name: generated
+  literals:
+  - key=value
+generatorOptions:
+  disableNameSuffixHash: true
+`,
+		"overlay/replicas.yaml": `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  replicas: "3"
+`,
+		"remote/kustomization.yaml": `
+resources:
+- https://github.com/example/config//base?ref=v1
+`,
+		"remote-patch/kustomization.yaml": `
+resources:
+- ../base
+patches:
+- path: ` + server.URL + `/patch.yaml
+`,
+	})
+
+	type args struct {
+		dir string
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "overlays are rendered with the file every object is declared in as source",
+			args: args{dir: filepath.Join(repo, "overlay")},
+			want: map[string]string{
+				"site/config":    filepath.Join(repo, "overlay", "../base/configmap.yaml"),
+				"site/generated": filepath.Join(repo, "overlay", "kustomization.yaml"),
+			},
+		},
+		{
+			name:    "remote bases are rejected",
+			args:    args{dir: filepath.Join(repo, "remote")},
+			wantErr: true,
+		},
+		{
+			name:    "remote patches are rejected without being downloaded",
+			args:    args{dir: filepath.Join(repo, "remote-patch")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Build(tt.args.dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if requested {
+				t.Fatalf("Build() downloaded a remote file")
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			sources := map[string]string{}
+
+			for _, o := range got {
+				sources[o.GetNamespace()+"/"+o.GetName()] = o.Source.File
+
+				if len(o.GetAnnotations()) != 0 {
+					t.Errorf("Build() annotations = %v, want none", o.GetAnnotations())
+				}
+
+				if replicas, _, _ := unstructured.NestedString(o.Object, "data", "replicas"); o.GetName() == "config" && replicas != "3" {
+					t.Errorf("Build() replicas = %q, want the patched 3", replicas)
+				}
+			}
+
+			if !reflect.DeepEqual(sources, tt.want) {
+				t.Errorf("Build() sources = %v, want %v", sources, tt.want)
+			}
+		})
+	}
+}
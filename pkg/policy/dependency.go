@@ -0,0 +1,141 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	policyv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+// templateDependencies returns the dependencies of the Policy and the extra ones of its template.
+// Policies and PolicySets without a namespace are looked up in the namespace of the Policy, as
+// ACM does.
+func templateDependencies(p policyv1.Policy, t policyv1.PolicyTemplate) []resource.Dependency {
+	dependencies := make([]resource.Dependency, 0, len(p.Spec.Dependencies)+len(t.ExtraDependencies))
+
+	for _, d := range append(append([]policyv1.PolicyDependency{}, p.Spec.Dependencies...), t.ExtraDependencies...) {
+		namespace := d.Namespace
+		if namespace == "" && (d.Kind == policyKind.Kind || d.Kind == policySetKind.Kind) {
+			namespace = p.Namespace
+		}
+
+		dependencies = append(dependencies, resource.Dependency{
+			Kind: d.Kind, Namespace: namespace, Name: d.Name, Compliance: string(d.Compliance),
+		})
+	}
+
+	return dependencies
+}
+
+// checkDependencyCycles reports the Policies that depend on themselves, directly or through the
+// Policies and templates they depend on, which ACM would leave pending forever. objects are the
+// Policies.
+func checkDependencyCycles(objects []resource.Object) []resource.Diagnostic {
+	sources := map[string]resource.Source{}
+	templates := map[string][]string{}
+	graph := map[string][]string{}
+
+	policies := map[string]policyv1.Policy{}
+
+	for _, o := range objects {
+		p := policyv1.Policy{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.Object, &p); err != nil {
+			continue
+		}
+
+		key := p.Namespace + "/" + p.Name
+		policies[key], sources[key] = p, o.Source
+
+		for _, t := range p.Spec.PolicyTemplates {
+			u := &unstructured.Unstructured{}
+			if err := u.UnmarshalJSON(t.ObjectDefinition.Raw); err == nil {
+				templates[u.GetName()] = append(templates[u.GetName()], key)
+			}
+		}
+	}
+
+	for key, p := range policies {
+		// the dependencies of the Policy along with the extra ones of all its templates
+		all := policyv1.PolicyTemplate{}
+		for _, t := range p.Spec.PolicyTemplates {
+			all.ExtraDependencies = append(all.ExtraDependencies, t.ExtraDependencies...)
+		}
+
+		for _, d := range templateDependencies(p, all) {
+			switch d.Kind {
+			case policyKind.Kind:
+				graph[key] = append(graph[key], d.Namespace+"/"+d.Name)
+			case policySetKind.Kind:
+				// PolicySets only group Policies, that have dependencies of their own
+			default:
+				graph[key] = append(graph[key], templates[d.Name]...)
+			}
+		}
+	}
+
+	var diags []resource.Diagnostic
+
+	for _, cycle := range findCycles(graph) {
+		diags = append(diags, resource.NewDiagnostic(sources[cycle[0]],
+			fmt.Sprintf("dependency cycle between Policies: %s", strings.Join(cycle, " -> "))))
+	}
+
+	return diags
+}
+
+// findCycles returns the cycles of the graph, each starting and ending with the same node.
+func findCycles(graph map[string][]string) [][]string {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	var (
+		cycles [][]string
+		path   []string
+		visit  func(node string)
+	)
+
+	state := map[string]int{}
+
+	visit = func(node string) {
+		state[node] = visiting
+		path = append(path, node)
+
+		for _, next := range graph[node] {
+			switch state[next] {
+			case visiting:
+				for i := range path {
+					if path[i] == next {
+						cycles = append(cycles, append(append([]string{}, path[i:]...), next))
+					}
+				}
+			case visited:
+			default:
+				visit(next)
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[node] = visited
+	}
+
+	nodes := make([]string, 0, len(graph))
+	for node := range graph {
+		nodes = append(nodes, node)
+	}
+
+	sort.Strings(nodes)
+
+	for _, node := range nodes {
+		if state[node] == 0 {
+			visit(node)
+		}
+	}
+
+	return cycles
+}
@@ -0,0 +1,160 @@
+package policy
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+)
+
+func TestExpandPolicies_dependencies(t *testing.T) {
+	objs, diags := resource.Decode([]byte(`
+apiVersion: policy.open-cluster-management.io/v1
+kind: Policy
+metadata:
+  name: config
+  namespace: ztp-site
+spec:
+  dependencies:
+  - apiVersion: policy.open-cluster-management.io/v1
+    kind: Policy
+    name: operators
+    compliance: Compliant
+  policy-templates:
+  - extraDependencies:
+    - apiVersion: policy.open-cluster-management.io/v1
+      kind: ConfigurationPolicy
+      name: operators
+      compliance: Compliant
+    ignorePending: true
+    objectDefinition:
+      apiVersion: policy.open-cluster-management.io/v1
+      kind: ConfigurationPolicy
+      metadata:
+        name: config
+      spec:
+        object-templates:
+        - complianceType: musthave
+          objectDefinition:
+            apiVersion: v1
+            kind: Namespace
+            metadata:
+              name: config
+---`+testPolicy("operators")+`
+apiVersion: policy.open-cluster-management.io/v1
+kind: Policy
+metadata:
+  name: legacy
+  namespace: ztp-site
+spec:
+  disabled: true
+  policy-templates:
+  - objectDefinition:
+      apiVersion: policy.open-cluster-management.io/v1
+      kind: ConfigurationPolicy
+      metadata:
+        name: legacy
+      spec:
+        object-templates:
+        - complianceType: musthave
+          objectDefinition:
+            apiVersion: v1
+            kind: Namespace
+            metadata:
+              name: legacy
+`), "policies.yaml")
+	if len(diags) > 0 {
+		t.Fatalf("Decode() diagnostics = %v", diags)
+	}
+
+	type args struct {
+		options Options
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want []string
+	}{
+		{
+			name: "disabled Policies are skipped",
+			want: []string{"config", "operators"},
+		},
+		{
+			name: "disabled Policies are expanded on demand",
+			args: args{options: Options{IncludeDisabled: true}},
+			want: []string{"config", "legacy", "operators"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, diags := ExpandPolicies(objs, tt.args.options)
+			if len(diags) > 0 {
+				t.Fatalf("ExpandPolicies() diagnostics = %v", diags)
+			}
+
+			var names []string
+
+			for _, o := range got {
+				names = append(names, o.GetName())
+
+				if o.GetName() != "config" {
+					continue
+				}
+
+				wantDependencies := []resource.Dependency{
+					{Kind: "Policy", Namespace: "ztp-site", Name: "operators", Compliance: "Compliant"},
+					{Kind: "ConfigurationPolicy", Name: "operators", Compliance: "Compliant"},
+				}
+				if o.Policy != "ztp-site/config" || o.Template != "config" || !o.IgnorePending ||
+					!reflect.DeepEqual(o.Dependencies, wantDependencies) {
+					t.Errorf("ExpandPolicies() policy = %s, template = %s, ignorePending = %t, dependencies = %v",
+						o.Policy, o.Template, o.IgnorePending, o.Dependencies)
+				}
+			}
+
+			sort.Strings(names)
+
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("ExpandPolicies() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandPolicies_dependencyCycles(t *testing.T) {
+	objs, diags := resource.Decode([]byte(`
+apiVersion: policy.open-cluster-management.io/v1
+kind: Policy
+metadata:
+  name: a
+  namespace: ztp-site
+spec:
+  dependencies:
+  - kind: Policy
+    name: b
+    compliance: Compliant
+---
+apiVersion: policy.open-cluster-management.io/v1
+kind: Policy
+metadata:
+  name: b
+  namespace: ztp-site
+spec:
+  dependencies:
+  - kind: Policy
+    name: a
+    compliance: Compliant
+`), "policies.yaml")
+	if len(diags) > 0 {
+		t.Fatalf("Decode() diagnostics = %v", diags)
+	}
+
+	_, diags = ExpandPolicies(objs, Options{})
+	if len(diags) != 1 || !strings.Contains(diags[0].Reason, "ztp-site/a -> ztp-site/b -> ztp-site/a") {
+		t.Errorf("ExpandPolicies() diagnostics = %v, want the cycle reported", diags)
+	}
+}
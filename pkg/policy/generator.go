@@ -0,0 +1,193 @@
+package policy
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+	"github.com/openshift-kni/reference-validator/pkg/util"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	configurationPolicyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+)
+
+const (
+	// GeneratorGroup is the API group of the PolicyGenerator kustomize plugin configuration.
+	GeneratorGroup = "policy.open-cluster-management.io"
+	generatorKind  = "PolicyGenerator"
+)
+
+// policyGenerator holds the fields of a PolicyGenerator used to generate the CRs of its policies.
+type policyGenerator struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	PolicyDefaults generatorOptions  `json:"policyDefaults,omitempty"`
+	Policies       []generatorPolicy `json:"policies,omitempty"`
+}
+
+// generatorOptions are the options a manifest inherits from its policy, which inherits them from
+// the policy defaults.
+type generatorOptions struct {
+	ComplianceType         string `json:"complianceType,omitempty"`
+	MetadataComplianceType string `json:"metadataComplianceType,omitempty"`
+}
+
+type generatorPolicy struct {
+	generatorOptions `json:",inline"`
+	Name             string              `json:"name"`
+	Manifests        []generatorManifest `json:"manifests,omitempty"`
+}
+
+type generatorManifest struct {
+	generatorOptions `json:",inline"`
+	// Path is a file or a directory relative to the PolicyGenerator.
+	Path    string                   `json:"path"`
+	Patches []map[string]interface{} `json:"patches,omitempty"`
+}
+
+// ExpandPolicyGenerators replaces every PolicyGenerator with the CRs of the manifests of its
+// policies, patched, and with the compliance types the generated Policies would evaluate them
+// with. The objects read from the manifests of a PolicyGenerator are left out, since they are
+// already part of its expansion.
+func ExpandPolicyGenerators(objects []resource.Object) ([]resource.Object, []resource.Diagnostic) {
+	var (
+		expanded []resource.Object
+		diags    []resource.Diagnostic
+	)
+
+	manifestFiles := map[string]bool{}
+
+	for _, o := range objects {
+		if !isPolicyGenerator(o) {
+			continue
+		}
+
+		generator := policyGenerator{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.Object, &generator); err != nil {
+			diags = append(diags, resource.NewDiagnostic(o.Source, fmt.Sprintf("invalid PolicyGenerator: %v", err)))
+
+			continue
+		}
+
+		crs, generatorDiags := generate(generator, o.Source, manifestFiles)
+		expanded = append(expanded, crs...)
+		diags = append(diags, generatorDiags...)
+	}
+
+	for _, o := range objects {
+		if !isPolicyGenerator(o) && !manifestFiles[o.Source.File] {
+			expanded = append(expanded, o)
+		}
+	}
+
+	return expanded, diags
+}
+
+func isPolicyGenerator(o resource.Object) bool {
+	gvk := o.GroupVersionKind()
+
+	return gvk.Group == GeneratorGroup && gvk.Kind == generatorKind
+}
+
+// generate reads the manifests of the PolicyGenerator read from src, and records the files it
+// read in manifestFiles.
+func generate(generator policyGenerator, src resource.Source, manifestFiles map[string]bool) ([]resource.Object, []resource.Diagnostic) {
+	slog.Info(fmt.Sprintf("generating %s --->", generator.Metadata.Name))
+
+	var (
+		crs   []resource.Object
+		diags []resource.Diagnostic
+	)
+
+	for _, p := range generator.Policies {
+		for _, m := range p.Manifests {
+			files, err := util.GetFileNames(filepath.Join(filepath.Dir(src.File), m.Path))
+			if err != nil {
+				diags = append(diags, resource.NewDiagnostic(src, fmt.Sprintf("invalid manifest of policy %s: %v", p.Name, err)))
+
+				continue
+			}
+
+			var manifests []resource.Object
+
+			for _, f := range files {
+				manifestFiles[f] = true
+				fileObjects, fileDiags := resource.ReadFile(f)
+				manifests = append(manifests, fileObjects...)
+				diags = append(diags, fileDiags...)
+			}
+
+			for i, patch := range m.Patches {
+				if !applyPatch(manifests, patch) {
+					diags = append(diags, resource.NewDiagnostic(src,
+						fmt.Sprintf("patch %d of manifest %s of policy %s does not match any object", i, m.Path, p.Name)))
+				}
+			}
+
+			options := resolveOptions(m.generatorOptions, p.generatorOptions, generator.PolicyDefaults)
+
+			for _, cr := range manifests {
+				slog.Info(fmt.Sprintf("found CR %s", cr.GetName()))
+
+				cr.ComplianceType = configurationPolicyv1.ComplianceType(options.ComplianceType)
+				cr.MetadataComplianceType = configurationPolicyv1.MetadataComplianceType(options.MetadataComplianceType)
+				crs = append(crs, cr)
+			}
+		}
+	}
+
+	return crs, diags
+}
+
+// resolveOptions returns the first option set at the manifest, policy or defaults level, with
+// the musthave compliance type the generator uses when none is set.
+func resolveOptions(levels ...generatorOptions) generatorOptions {
+	resolved := generatorOptions{ComplianceType: string(configurationPolicyv1.MustHave)}
+
+	for i := len(levels) - 1; i >= 0; i-- {
+		if levels[i].ComplianceType != "" {
+			resolved.ComplianceType = levels[i].ComplianceType
+		}
+
+		if levels[i].MetadataComplianceType != "" {
+			resolved.MetadataComplianceType = levels[i].MetadataComplianceType
+		}
+	}
+
+	return resolved
+}
+
+// applyPatch merges the patch into the manifest objects it identifies by kind, name and, when
+// set, apiVersion and namespace. Like the generator, a patch of a manifest holding a single
+// object may leave out its identity. It returns false when no object matches.
+func applyPatch(manifests []resource.Object, patch map[string]interface{}) bool {
+	target := unstructured.Unstructured{Object: patch}
+	anonymous := target.GetKind() == "" && target.GetName() == ""
+	matched := false
+
+	for i := range manifests {
+		m := &manifests[i]
+
+		if anonymous && len(manifests) != 1 {
+			break
+		}
+
+		if !anonymous && (target.GetKind() != m.GetKind() || target.GetName() != m.GetName() ||
+			(target.GetAPIVersion() != "" && target.GetAPIVersion() != m.GetAPIVersion()) ||
+			(target.GetNamespace() != "" && target.GetNamespace() != m.GetNamespace())) {
+			continue
+		}
+
+		merged, ok := util.StrategicMerge(m.Object, patch).(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		m.Object = merged
+		matched = true
+	}
+
+	return matched
+}
@@ -0,0 +1,105 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	configurationPolicyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+)
+
+func TestExpandPolicyGenerators(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, content := range map[string]string{
+		"generator.yaml": `
+apiVersion: policy.open-cluster-management.io/v1
+kind: PolicyGenerator
+metadata:
+  name: site-policies
+policyDefaults:
+  namespace: policies
+  metadataComplianceType: musthave
+policies:
+- name: config
+  complianceType: mustonlyhave
+  manifests:
+  - path: manifests/configmap.yaml
+    patches:
+    - data:
+        mode: performance
+  - path: manifests/ns
+    complianceType: musthave
+    patches:
+    - apiVersion: v1
+      kind: Namespace
+      metadata:
+        name: site
+        labels:
+          site: east
+    - kind: Namespace
+      metadata:
+        name: unknown
+`,
+		"manifests/configmap.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n  namespace: site\ndata:\n  mode: balanced\n",
+		"manifests/ns/ns.yaml":     "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: site\n---\napiVersion: v1\nkind: Namespace\nmetadata:\n  name: logging\n",
+		"other.yaml":               "apiVersion: v1\nkind: Secret\nmetadata:\n  name: other\n  namespace: site\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatalf("could not create test directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("could not write test file: %v", err)
+		}
+	}
+
+	objects, diags := resource.ReadDirs([]string{dir})
+	if len(diags) > 0 {
+		t.Fatalf("ReadDirs() diagnostics = %v", diags)
+	}
+
+	got, diags := ExpandPolicyGenerators(objects)
+
+	wantDiags := []resource.Diagnostic{{
+		File:   filepath.Join(dir, "generator.yaml"),
+		Reason: "patch 1 of manifest manifests/ns of policy config does not match any object",
+	}}
+	if !reflect.DeepEqual(diags, wantDiags) {
+		t.Errorf("ExpandPolicyGenerators() diagnostics = %v, want %v", diags, wantDiags)
+	}
+
+	type want struct {
+		name                   string
+		complianceType         configurationPolicyv1.ComplianceType
+		metadataComplianceType configurationPolicyv1.MetadataComplianceType
+	}
+
+	wantObjects := []want{
+		{name: "config", complianceType: "mustonlyhave", metadataComplianceType: "musthave"},
+		{name: "site", complianceType: "musthave", metadataComplianceType: "musthave"},
+		{name: "logging", complianceType: "musthave", metadataComplianceType: "musthave"},
+		{name: "other"},
+	}
+
+	var gotObjects []want
+	for _, o := range got {
+		gotObjects = append(gotObjects, want{name: o.GetName(), complianceType: o.ComplianceType, metadataComplianceType: o.MetadataComplianceType})
+	}
+
+	if !reflect.DeepEqual(gotObjects, wantObjects) {
+		t.Fatalf("ExpandPolicyGenerators() = %v, want %v", gotObjects, wantObjects)
+	}
+
+	if mode, _, _ := unstructured.NestedString(got[0].Object, "data", "mode"); mode != "performance" {
+		t.Errorf("ConfigMap data.mode = %q, want the patched value", mode)
+	}
+
+	if labels := got[1].GetLabels(); labels["site"] != "east" {
+		t.Errorf("Namespace labels = %v, want the patched label", labels)
+	}
+}
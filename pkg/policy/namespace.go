@@ -0,0 +1,187 @@
+package policy
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sort"
+
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	configurationPolicyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+	"sigs.k8s.io/kustomize/kyaml/openapi"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// NamespaceSelection is what the namespaceSelector of ConfigurationPolicies selects from.
+type NamespaceSelection struct {
+	// Namespaces maps the name of every namespace to its labels.
+	Namespaces map[string]map[string]string
+	// Namespaced is true for the kinds whose objects belong to a namespace. The objects of other
+	// kinds are not expanded.
+	Namespaced func(schema.GroupVersionKind) bool
+}
+
+// NewNamespaceSelection selects from the Namespaces among the resources, or from the namespaces
+// names, which have no labels, when set. The scope of a kind is learned from the references and
+// resources, which hold objects of namespaced kinds in a namespace and objects of cluster-scoped
+// kinds without one, then from the built-in Kubernetes kinds. Other kinds are namespaced, as a
+// namespaceSelector only applies to namespaced objects.
+func NewNamespaceSelection(resources, references []resource.Object, names []string) NamespaceSelection {
+	selection := NamespaceSelection{Namespaces: map[string]map[string]string{}}
+	namespaced := map[schema.GroupKind]bool{}
+
+	for _, o := range append(append([]resource.Object{}, references...), resources...) {
+		gk := o.GroupVersionKind().GroupKind()
+
+		switch {
+		case o.GetNamespace() != "":
+			namespaced[gk] = true
+		case o.NamespaceSelector == nil:
+			// objects left without namespace by a namespaceSelector tell nothing of their kind
+			if _, found := namespaced[gk]; !found {
+				namespaced[gk] = false
+			}
+		}
+	}
+
+	for _, o := range resources {
+		if gvk := o.GroupVersionKind(); len(names) == 0 && gvk.Group == "" && gvk.Kind == "Namespace" {
+			selection.Namespaces[o.GetName()] = o.GetLabels()
+		}
+	}
+
+	for _, name := range names {
+		selection.Namespaces[name] = nil
+	}
+
+	selection.Namespaced = func(gvk schema.GroupVersionKind) bool {
+		if scoped, found := namespaced[gvk.GroupKind()]; found {
+			return scoped
+		}
+
+		if scoped, found := openapi.IsNamespaceScoped(yaml.TypeMeta{APIVersion: gvk.GroupVersion().String(), Kind: gvk.Kind}); found {
+			return scoped
+		}
+
+		return true
+	}
+
+	return selection
+}
+
+// ExpandNamespaceSelectors replaces every object extracted from a ConfigurationPolicy without a
+// namespace with a copy in each namespace its namespaceSelector selects, leaving the other objects
+// untouched. Objects of kinds that are not namespaced keep no namespace.
+func ExpandNamespaceSelectors(objects []resource.Object, selection NamespaceSelection) ([]resource.Object, []resource.Diagnostic) {
+	var (
+		expanded []resource.Object
+		diags    []resource.Diagnostic
+	)
+
+	for _, o := range objects {
+		if o.NamespaceSelector == nil {
+			expanded = append(expanded, o)
+
+			continue
+		}
+
+		selector := o.NamespaceSelector
+		o.NamespaceSelector = nil
+
+		if !selection.Namespaced(o.GroupVersionKind()) {
+			expanded = append(expanded, o)
+
+			continue
+		}
+
+		namespaces, err := selection.selectNamespaces(*selector)
+		if err != nil {
+			diags = append(diags, resource.NewDiagnostic(o.Source, fmt.Sprintf("invalid namespaceSelector for %s %s: %v",
+				o.GetKind(), o.GetName(), err)))
+
+			continue
+		}
+
+		if len(namespaces) == 0 {
+			slog.Info(fmt.Sprintf("namespaceSelector %s of %s %s selects no namespace", selector, o.GetKind(), o.GetName()))
+		}
+
+		for _, namespace := range namespaces {
+			copied := o
+			copied.Unstructured = *o.DeepCopy()
+			copied.SetNamespace(namespace)
+			expanded = append(expanded, copied)
+		}
+	}
+
+	return expanded, diags
+}
+
+// selectNamespaces returns the sorted namespaces that match the label selectors of the target and
+// one of its include patterns, when set, but none of its exclude patterns, as the configuration
+// policy controller does.
+func (s NamespaceSelection) selectNamespaces(target configurationPolicyv1.Target) ([]string, error) {
+	labelSelector := &metav1.LabelSelector{}
+	if target.MatchLabels != nil {
+		labelSelector.MatchLabels = *target.MatchLabels
+	}
+
+	if target.MatchExpressions != nil {
+		labelSelector.MatchExpressions = *target.MatchExpressions
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector: %w", err)
+	}
+
+	var selected []string
+
+	for name, namespaceLabels := range s.Namespaces {
+		if !selector.Matches(labels.Set(namespaceLabels)) {
+			continue
+		}
+
+		included, err := matchesAny(target.Include, name)
+		if err != nil {
+			return nil, err
+		}
+
+		excluded, err := matchesAny(target.Exclude, name)
+		if err != nil {
+			return nil, err
+		}
+
+		if (len(target.Include) == 0 || included) && !excluded {
+			selected = append(selected, name)
+		}
+	}
+
+	sort.Strings(selected)
+
+	return selected, nil
+}
+
+// matchesAny is true when the name matches one of the filepath patterns.
+func matchesAny(patterns []configurationPolicyv1.NonEmptyString, name string) (bool, error) {
+	for _, pattern := range patterns {
+		matched, err := filepath.Match(string(pattern), name)
+		if err != nil {
+			return false, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+		}
+
+		if matched {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// isEmptyTarget is true for a namespaceSelector that selects no namespace.
+func isEmptyTarget(target configurationPolicyv1.Target) bool {
+	return len(target.Include) == 0 && target.MatchLabels == nil && target.MatchExpressions == nil
+}
@@ -0,0 +1,159 @@
+package policy
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+)
+
+func TestExpandNamespaceSelectors(t *testing.T) {
+	objs, diags := resource.Decode([]byte(`
+apiVersion: policy.open-cluster-management.io/v1
+kind: Policy
+metadata:
+  name: network
+  namespace: ztp-site
+spec:
+  policy-templates:
+  - objectDefinition:
+      apiVersion: policy.open-cluster-management.io/v1
+      kind: ConfigurationPolicy
+      metadata:
+        name: network
+      spec:
+        namespaceSelector:
+          include:
+          - "app-*"
+          - default
+          exclude:
+          - "*-test"
+          matchLabels:
+            network: isolated
+        object-templates:
+        - complianceType: musthave
+          objectDefinition:
+            apiVersion: networking.k8s.io/v1
+            kind: NetworkPolicy
+            metadata:
+              name: deny-all
+        - complianceType: musthave
+          objectDefinition:
+            apiVersion: networking.k8s.io/v1
+            kind: NetworkPolicy
+            metadata:
+              name: allow-dns
+              namespace: openshift-dns
+        - complianceType: musthave
+          objectDefinition:
+            apiVersion: rbac.authorization.k8s.io/v1
+            kind: ClusterRole
+            metadata:
+              name: network-viewer
+`), "policy.yaml")
+	if len(diags) > 0 {
+		t.Fatalf("Decode() diagnostics = %v", diags)
+	}
+
+	references, diags := ExpandPolicies(objs, Options{})
+	if len(diags) > 0 {
+		t.Fatalf("ExpandPolicies() diagnostics = %v", diags)
+	}
+
+	namespaces, diags := resource.Decode([]byte(`
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: app-frontend
+  labels:
+    network: isolated
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: app-test
+  labels:
+    network: isolated
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: app-backend
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: default
+  labels:
+    network: isolated
+`), "namespaces.yaml")
+	if len(diags) > 0 {
+		t.Fatalf("Decode() diagnostics = %v", diags)
+	}
+
+	resources, diags := resource.Decode([]byte(`
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: deny-all
+  namespace: app-frontend
+`), "resources.yaml")
+	if len(diags) > 0 {
+		t.Fatalf("Decode() diagnostics = %v", diags)
+	}
+
+	resources = append(resources, namespaces...)
+
+	type args struct {
+		resources []resource.Object
+		names     []string
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want []string
+	}{
+		{
+			name: "objects without namespace are expanded into the selected Namespaces of the resources",
+			args: args{resources: resources},
+			want: []string{"/network-viewer", "app-frontend/deny-all", "default/deny-all", "openshift-dns/allow-dns"},
+		},
+		{
+			name: "label selectors match no namespace given by name",
+			args: args{resources: resources, names: []string{"app-frontend", "default"}},
+			want: []string{"/network-viewer", "openshift-dns/allow-dns"},
+		},
+		{
+			name: "objects of kinds absent from the resources are expanded too",
+			args: args{resources: namespaces},
+			want: []string{"/network-viewer", "app-frontend/deny-all", "default/deny-all", "openshift-dns/allow-dns"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, diags := ExpandNamespaceSelectors(references, NewNamespaceSelection(tt.args.resources, references, tt.args.names))
+			if len(diags) > 0 {
+				t.Fatalf("ExpandNamespaceSelectors() diagnostics = %v", diags)
+			}
+
+			var ids []string
+
+			for _, o := range got {
+				ids = append(ids, o.GetNamespace()+"/"+o.GetName())
+
+				if o.NamespaceSelector != nil {
+					t.Errorf("ExpandNamespaceSelectors() left the namespaceSelector of %s", o.GetName())
+				}
+			}
+
+			sort.Strings(ids)
+
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("ExpandNamespaceSelectors() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	policyv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+	policyv1beta1 "open-cluster-management.io/governance-policy-propagator/api/v1beta1"
+	placementrulev1 "open-cluster-management.io/multicloud-operators-subscription/pkg/apis/apps/placementrule/v1"
+)
+
+var (
+	errUnknownObject      = errors.New("unknown object")
+	errUnsupportedSubject = errors.New("unsupported subject kind")
+	policyKind            = policyv1.GroupVersion.WithKind("Policy").GroupKind()
+	policySetKind         = policyv1beta1.GroupVersion.WithKind("PolicySet").GroupKind()
+	placementBindingKind  = policyv1.GroupVersion.WithKind("PlacementBinding").GroupKind()
+	placementRuleKind     = schema.GroupKind{Group: "apps.open-cluster-management.io", Kind: "PlacementRule"}
+	placementKind         = schema.GroupKind{Group: "cluster.open-cluster-management.io", Kind: "Placement"}
+)
+
+// placementObjects are the kinds that only decide which clusters Policies are propagated to, and
+// that no cluster would hold as such.
+var placementObjects = map[schema.GroupKind]bool{
+	policySetKind: true, placementBindingKind: true, placementRuleKind: true, placementKind: true,
+}
+
+// placementKey identifies a PolicySet, PlacementRule or Placement of a namespace.
+type placementKey struct {
+	Kind schema.GroupKind
+	types.NamespacedName
+}
+
+// resolvePlacements leaves out the PolicySets, PlacementBindings, PlacementRules and Placements.
+// When clusterLabels is set, it also leaves out the Policies that no PlacementBinding propagates,
+// directly or through a PolicySet, to a cluster with these labels. Without any PlacementBinding,
+// as with the output of the PolicyGenerator without placements, every Policy is kept and this is
+// reported, rather than leaving nothing to compare.
+func resolvePlacements(objects []resource.Object, clusterLabels map[string]string) ([]resource.Object, []resource.Diagnostic) {
+	var (
+		resolved []resource.Object
+		diags    []resource.Diagnostic
+		policies []resource.Object
+		bindings int
+	)
+
+	index := map[placementKey]resource.Object{}
+
+	for _, o := range objects {
+		gk := o.GroupVersionKind().GroupKind()
+		if gk == policyKind || placementObjects[gk] {
+			index[placementKey{Kind: gk, NamespacedName: types.NamespacedName{Namespace: o.GetNamespace(), Name: o.GetName()}}] = o
+		}
+
+		switch gk {
+		case policyKind:
+			policies = append(policies, o)
+		case placementBindingKind:
+			bindings++
+		}
+	}
+
+	if len(clusterLabels) > 0 && bindings == 0 && len(policies) > 0 {
+		diags = append(diags, resource.NewDiagnostic(policies[0].Source,
+			fmt.Sprintf("no PlacementBinding places the %d Policies, they are all compared regardless of the cluster labels", len(policies))))
+		clusterLabels = nil
+	}
+
+	placed, placeDiags := placedPolicies(index, clusterLabels)
+	diags = append(diags, placeDiags...)
+
+	for _, o := range objects {
+		gk := o.GroupVersionKind().GroupKind()
+		if placementObjects[gk] {
+			continue
+		}
+
+		if len(clusterLabels) > 0 && gk == policyKind && !placed[types.NamespacedName{Namespace: o.GetNamespace(), Name: o.GetName()}] {
+			slog.Info(fmt.Sprintf("skipping Policy %s/%s, not placed on the cluster", o.GetNamespace(), o.GetName()))
+
+			continue
+		}
+
+		resolved = append(resolved, o)
+	}
+
+	return resolved, diags
+}
+
+// placedPolicies returns the Policies the PlacementBindings of the index bind, directly or through
+// their PolicySets, to a placement selecting a cluster with clusterLabels, or to any placement when
+// clusterLabels is not set.
+func placedPolicies(index map[placementKey]resource.Object, clusterLabels map[string]string) (map[types.NamespacedName]bool, []resource.Diagnostic) {
+	var diags []resource.Diagnostic
+
+	placed := map[types.NamespacedName]bool{}
+
+	for key, o := range index {
+		if key.Kind != placementBindingKind {
+			continue
+		}
+
+		binding := policyv1.PlacementBinding{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.Object, &binding); err != nil {
+			diags = append(diags, resource.NewDiagnostic(o.Source, fmt.Sprintf("invalid PlacementBinding: %v", err)))
+
+			continue
+		}
+
+		placementRef := placementKey{
+			Kind:           schema.GroupKind{Group: binding.PlacementRef.APIGroup, Kind: binding.PlacementRef.Kind},
+			NamespacedName: types.NamespacedName{Namespace: binding.Namespace, Name: binding.PlacementRef.Name},
+		}
+
+		placement, found := index[placementRef]
+		if !found {
+			diags = append(diags, resource.NewDiagnostic(o.Source, fmt.Sprintf("PlacementBinding %s refers to unknown %s %s",
+				binding.Name, placementRef.Kind.Kind, placementRef.Name)))
+
+			continue
+		}
+
+		if len(clusterLabels) > 0 {
+			selected, err := selectsCluster(placement, clusterLabels)
+			if err != nil {
+				diags = append(diags, resource.NewDiagnostic(placement.Source, fmt.Sprintf("invalid %s: %v", placementRef.Kind.Kind, err)))
+
+				continue
+			}
+
+			if !selected {
+				continue
+			}
+		}
+
+		for _, subject := range binding.Subjects {
+			policies, err := subjectPolicies(index, binding.Namespace, subject)
+			if err != nil {
+				diags = append(diags, resource.NewDiagnostic(o.Source, fmt.Sprintf("PlacementBinding %s: %v", binding.Name, err)))
+			}
+
+			for _, p := range policies {
+				if _, found := index[placementKey{Kind: policyKind, NamespacedName: p}]; !found {
+					diags = append(diags, resource.NewDiagnostic(o.Source, fmt.Sprintf("PlacementBinding %s: %v: Policy %s",
+						binding.Name, errUnknownObject, p.Name)))
+				}
+
+				placed[p] = true
+			}
+		}
+	}
+
+	return placed, diags
+}
+
+// subjectPolicies returns the Policy of a subject, or the member Policies of a PolicySet subject.
+func subjectPolicies(index map[placementKey]resource.Object, namespace string, subject policyv1.Subject) ([]types.NamespacedName, error) {
+	name := types.NamespacedName{Namespace: namespace, Name: subject.Name}
+
+	switch subject.Kind {
+	case policyKind.Kind:
+		return []types.NamespacedName{name}, nil
+	case policySetKind.Kind:
+		o, found := index[placementKey{Kind: policySetKind, NamespacedName: name}]
+		if !found {
+			return nil, fmt.Errorf("%w: PolicySet %s", errUnknownObject, subject.Name)
+		}
+
+		policySet := policyv1beta1.PolicySet{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.Object, &policySet); err != nil {
+			return nil, fmt.Errorf("invalid PolicySet %s: %w", subject.Name, err)
+		}
+
+		members := make([]types.NamespacedName, 0, len(policySet.Spec.Policies))
+		for _, member := range policySet.Spec.Policies {
+			members = append(members, types.NamespacedName{Namespace: namespace, Name: string(member)})
+		}
+
+		return members, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedSubject, subject.Kind)
+	}
+}
+
+// selectsCluster is true when the PlacementRule or Placement selects a cluster with the labels.
+// The name of a cluster is its name label, as set by ACM. Placements select the clusters matching
+// the label and claim selectors of any of their predicates, claims being looked up in the labels.
+func selectsCluster(placement resource.Object, clusterLabels map[string]string) (bool, error) {
+	if placement.GroupVersionKind().GroupKind() == placementRuleKind {
+		rule := placementrulev1.PlacementRule{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(placement.Object, &rule); err != nil {
+			return false, fmt.Errorf("could not convert PlacementRule: %w", err)
+		}
+
+		if len(rule.Spec.Clusters) > 0 && !hasClusterName(rule.Spec.Clusters, clusterLabels["name"]) {
+			return false, nil
+		}
+
+		return matchesLabelSelector(rule.Spec.ClusterSelector, clusterLabels)
+	}
+
+	predicates, _, _ := unstructured.NestedSlice(placement.Object, "spec", "predicates")
+	if len(predicates) == 0 {
+		return true, nil
+	}
+
+	for _, predicate := range predicates {
+		predicateMap, _ := predicate.(map[string]interface{})
+		selected := true
+
+		for _, selectorField := range []string{"labelSelector", "claimSelector"} {
+			selectorMap, found, _ := unstructured.NestedMap(predicateMap, "requiredClusterSelector", selectorField)
+			if !found {
+				continue
+			}
+
+			selector := &metav1.LabelSelector{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(selectorMap, selector); err != nil {
+				return false, fmt.Errorf("invalid %s: %w", selectorField, err)
+			}
+
+			matches, err := matchesLabelSelector(selector, clusterLabels)
+			if err != nil {
+				return false, err
+			}
+
+			selected = selected && matches
+		}
+
+		if selected {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// hasClusterName is true when the clusters of a PlacementRule include name.
+func hasClusterName(clusters []placementrulev1.GenericClusterReference, name string) bool {
+	for _, c := range clusters {
+		if c.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesLabelSelector is true when the labels match the selector, a nil selector matching any
+// labels.
+func matchesLabelSelector(selector *metav1.LabelSelector, clusterLabels map[string]string) (bool, error) {
+	if selector == nil {
+		return true, nil
+	}
+
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false, fmt.Errorf("invalid label selector: %w", err)
+	}
+
+	return s.Matches(labels.Set(clusterLabels)), nil
+}
@@ -0,0 +1,235 @@
+package policy
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+)
+
+func testPolicy(name string) string {
+	return fmt.Sprintf(`
+apiVersion: policy.open-cluster-management.io/v1
+kind: Policy
+metadata:
+  name: %[1]s
+  namespace: ztp-site
+spec:
+  policy-templates:
+  - objectDefinition:
+      apiVersion: policy.open-cluster-management.io/v1
+      kind: ConfigurationPolicy
+      metadata:
+        name: %[1]s
+      spec:
+        object-templates:
+        - complianceType: musthave
+          objectDefinition:
+            apiVersion: v1
+            kind: Namespace
+            metadata:
+              name: %[1]s
+---`, name)
+}
+
+func TestExpandPolicies_placements(t *testing.T) {
+	objs, diags := resource.Decode([]byte(testPolicy("common")+testPolicy("sno1-config")+testPolicy("group-config")+`
+apiVersion: policy.open-cluster-management.io/v1beta1
+kind: PolicySet
+metadata:
+  name: du
+  namespace: ztp-site
+spec:
+  policies:
+  - common
+---
+apiVersion: cluster.open-cluster-management.io/v1beta1
+kind: Placement
+metadata:
+  name: du
+  namespace: ztp-site
+spec:
+  predicates:
+  - requiredClusterSelector:
+      labelSelector:
+        matchLabels:
+          du-profile: "4.14"
+---
+apiVersion: policy.open-cluster-management.io/v1
+kind: PlacementBinding
+metadata:
+  name: du
+  namespace: ztp-site
+placementRef:
+  apiGroup: cluster.open-cluster-management.io
+  kind: Placement
+  name: du
+subjects:
+- apiGroup: policy.open-cluster-management.io
+  kind: PolicySet
+  name: du
+---
+apiVersion: apps.open-cluster-management.io/v1
+kind: PlacementRule
+metadata:
+  name: sno1
+  namespace: ztp-site
+spec:
+  clusters:
+  - name: sno1
+---
+apiVersion: policy.open-cluster-management.io/v1
+kind: PlacementBinding
+metadata:
+  name: sno1
+  namespace: ztp-site
+placementRef:
+  apiGroup: apps.open-cluster-management.io
+  kind: PlacementRule
+  name: sno1
+subjects:
+- apiGroup: policy.open-cluster-management.io
+  kind: Policy
+  name: sno1-config
+---
+apiVersion: apps.open-cluster-management.io/v1
+kind: PlacementRule
+metadata:
+  name: group
+  namespace: ztp-site
+spec:
+  clusterSelector:
+    matchExpressions:
+    - key: group-du-sno
+      operator: Exists
+---
+apiVersion: policy.open-cluster-management.io/v1
+kind: PlacementBinding
+metadata:
+  name: group
+  namespace: ztp-site
+placementRef:
+  apiGroup: apps.open-cluster-management.io
+  kind: PlacementRule
+  name: group
+subjects:
+- apiGroup: policy.open-cluster-management.io
+  kind: Policy
+  name: group-config
+`), "policies.yaml")
+	if len(diags) > 0 {
+		t.Fatalf("Decode() diagnostics = %v", diags)
+	}
+
+	type args struct {
+		clusterLabels map[string]string
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want []string
+	}{
+		{
+			name: "all Policies are expanded without cluster labels",
+			want: []string{"common", "group-config", "sno1-config"},
+		},
+		{
+			name: "Policies of a PolicySet are placed with it",
+			args: args{clusterLabels: map[string]string{"name": "sno2", "du-profile": "4.14"}},
+			want: []string{"common"},
+		},
+		{
+			name: "Policies are placed by cluster name and label selector",
+			args: args{clusterLabels: map[string]string{"name": "sno1", "group-du-sno": ""}},
+			want: []string{"group-config", "sno1-config"},
+		},
+		{
+			name: "no Policy is placed on an unselected cluster",
+			args: args{clusterLabels: map[string]string{"name": "hub"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, diags := ExpandPolicies(objs, Options{ClusterLabels: tt.args.clusterLabels})
+			if len(diags) > 0 {
+				t.Fatalf("ExpandPolicies() diagnostics = %v", diags)
+			}
+
+			var names []string
+			for _, o := range got {
+				names = append(names, o.GetName())
+			}
+
+			sort.Strings(names)
+
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("ExpandPolicies() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandPolicies_unknownPlacements(t *testing.T) {
+	objs, diags := resource.Decode([]byte(`
+apiVersion: policy.open-cluster-management.io/v1
+kind: PlacementBinding
+metadata:
+  name: du
+  namespace: ztp-site
+placementRef:
+  apiGroup: apps.open-cluster-management.io
+  kind: PlacementRule
+  name: missing
+subjects:
+- apiGroup: policy.open-cluster-management.io
+  kind: PolicySet
+  name: du
+---
+apiVersion: apps.open-cluster-management.io/v1
+kind: PlacementRule
+metadata:
+  name: all
+  namespace: ztp-site
+---
+apiVersion: policy.open-cluster-management.io/v1
+kind: PlacementBinding
+metadata:
+  name: all
+  namespace: ztp-site
+placementRef:
+  apiGroup: apps.open-cluster-management.io
+  kind: PlacementRule
+  name: all
+subjects:
+- apiGroup: policy.open-cluster-management.io
+  kind: PolicySet
+  name: missing
+- apiGroup: policy.open-cluster-management.io
+  kind: Policy
+  name: missing
+`), "policies.yaml")
+	if len(diags) > 0 {
+		t.Fatalf("Decode() diagnostics = %v", diags)
+	}
+
+	got, diags := ExpandPolicies(objs, Options{ClusterLabels: map[string]string{"name": "sno1"}})
+	if len(got) != 0 || len(diags) != 3 {
+		t.Errorf("ExpandPolicies() = %d objects, diagnostics = %v, want the unknown placement, PolicySet and Policy reported", len(got), diags)
+	}
+}
+
+func TestExpandPolicies_withoutPlacementBindings(t *testing.T) {
+	objs, diags := resource.Decode([]byte(testPolicy("common")+testPolicy("sno1-config")), "policies.yaml")
+	if len(diags) > 0 {
+		t.Fatalf("Decode() diagnostics = %v", diags)
+	}
+
+	got, diags := ExpandPolicies(objs, Options{ClusterLabels: map[string]string{"name": "sno1"}})
+	if len(got) != 2 || len(diags) != 1 {
+		t.Errorf("ExpandPolicies() = %d objects, diagnostics = %v, want both Policies kept and the missing PlacementBindings reported", len(got), diags)
+	}
+}
@@ -0,0 +1,173 @@
+// Package policy extracts the CRs that ACM Policies would create or check on a cluster.
+package policy
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	configurationPolicyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+	policyv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
+)
+
+// Options tune how Policies are expanded.
+type Options struct {
+	// TemplateValues is the data the hub and managed cluster templates are executed with.
+	TemplateValues map[string]interface{}
+	// TemplateObjects stand in for the objects of the hub and the managed cluster that templates
+	// look up, such as ConfigMaps and Secrets.
+	TemplateObjects []resource.Object
+	// TemplateWildcards replaces the templates of object-templates that cannot be rendered with
+	// the Wildcard placeholder instead of reporting them.
+	TemplateWildcards bool
+	// ClusterLabels are the labels of the managed cluster the objects are compared for. When set,
+	// only the Policies a PlacementBinding places on that cluster are expanded.
+	ClusterLabels map[string]string
+	// IncludeDisabled expands the disabled Policies too, which ACM does not propagate.
+	IncludeDisabled bool
+}
+
+// configurationPolicyTemplate is a ConfigurationPolicy along with the policy-template holding it.
+type configurationPolicyTemplate struct {
+	configurationPolicyv1.ConfigurationPolicy
+	template policyv1.PolicyTemplate
+}
+
+// ExpandPolicies replaces every Policy with the CRs of its object-templates, leaving the other
+// objects untouched. PolicySets, PlacementBindings and their placements are resolved and dropped,
+// see Options.ClusterLabels, and the dependencies between the expanded Policies are checked for
+// cycles.
+func ExpandPolicies(uList []resource.Object, options Options) ([]resource.Object, []resource.Diagnostic) {
+	uList, diags := resolvePlacements(uList, options.ClusterLabels)
+
+	// Extract the main CR if policy
+	var (
+		uListWithoutP []resource.Object
+		expanded      []resource.Object
+	)
+
+	for _, curUnstructured := range uList {
+		if curUnstructured.GetKind() == "Policy" {
+			policy := policyv1.Policy{}
+
+			err := runtime.DefaultUnstructuredConverter.FromUnstructured(curUnstructured.Object, &policy)
+			if err != nil {
+				diags = append(diags, resource.NewDiagnostic(curUnstructured.Source, fmt.Sprintf("invalid Policy CR: %v", err)))
+
+				continue
+			}
+
+			if policy.Spec.Disabled && !options.IncludeDisabled {
+				slog.Info(fmt.Sprintf("skipping disabled Policy %s/%s", policy.Namespace, policy.Name))
+
+				continue
+			}
+
+			crs, policyDiags := ObjectTemplates(policy, curUnstructured.Source, options)
+			diags = append(diags, policyDiags...)
+			uListWithoutP = append(uListWithoutP, crs...)
+			expanded = append(expanded, curUnstructured)
+
+			continue
+		}
+
+		uListWithoutP = append(uListWithoutP, curUnstructured)
+	}
+
+	return uListWithoutP, append(diags, checkDependencyCycles(expanded)...)
+}
+
+func getConfigurationPolicy(p policyv1.Policy, src resource.Source) ([]configurationPolicyTemplate, []resource.Diagnostic) {
+	var (
+		cPs   []configurationPolicyTemplate
+		diags []resource.Diagnostic
+	)
+
+	for _, policyTemplate := range p.Spec.PolicyTemplates {
+		uConfigPolicy := &unstructured.Unstructured{}
+
+		err := uConfigPolicy.UnmarshalJSON(policyTemplate.ObjectDefinition.Raw)
+		if err != nil {
+			diags = append(diags, resource.NewDiagnostic(src, fmt.Sprintf("could not unmarshal policy-template of %s: %v", p.Name, err)))
+
+			continue
+		}
+
+		tConfigPolicy := configurationPolicyv1.ConfigurationPolicy{}
+
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(uConfigPolicy.UnstructuredContent(), &tConfigPolicy)
+		if err != nil {
+			diags = append(diags, resource.NewDiagnostic(src, fmt.Sprintf("invalid ConfigurationPolicy in %s: %v", p.Name, err)))
+
+			continue
+		}
+
+		cPs = append(cPs, configurationPolicyTemplate{ConfigurationPolicy: tConfigPolicy, template: *policyTemplate})
+	}
+
+	return cPs, diags
+}
+
+// ObjectTemplates extracts the CRs of every object-template of the Policy, rendering the
+// object-templates-raw ones, along with the compliance types they must be evaluated with and the
+// dependencies of their policy-template. src is the location of the Policy.
+func ObjectTemplates(p policyv1.Policy, src resource.Source, options Options) ([]resource.Object, []resource.Diagnostic) {
+	slog.Info(fmt.Sprintf("extracting %s --->", p.Name))
+	cPolicies, diags := getConfigurationPolicy(p, src)
+	r := newRenderer(options)
+
+	var objT []resource.Object
+
+	for _, cPolicy := range cPolicies {
+		objectTemplates := cPolicy.Spec.ObjectTemplates
+		dependencies := templateDependencies(p, cPolicy.template)
+
+		if cPolicy.Spec.ObjectTemplatesRaw != "" {
+			rendered, err := r.renderObjectTemplatesRaw(cPolicy.Spec.ObjectTemplatesRaw, p.Namespace)
+			if err != nil {
+				diags = append(diags, resource.NewDiagnostic(src, fmt.Sprintf("invalid object-templates-raw in %s: %v", cPolicy.Name, err)))
+
+				continue
+			}
+
+			objectTemplates = append(objectTemplates, rendered...)
+		}
+
+		for _, ot := range objectTemplates {
+			customResource := &unstructured.Unstructured{}
+
+			err := customResource.UnmarshalJSON(ot.ObjectDefinition.Raw)
+			if err != nil {
+				diags = append(diags, resource.NewDiagnostic(src, fmt.Sprintf("invalid object-template in %s: %v", cPolicy.Name, err)))
+
+				continue
+			}
+
+			for _, err := range r.renderObject(customResource.Object, p.Namespace) {
+				diags = append(diags, resource.NewDiagnostic(src, fmt.Sprintf("could not render template in %s: %v", cPolicy.Name, err)))
+			}
+
+			var namespaceSelector *configurationPolicyv1.Target
+			if customResource.GetNamespace() == "" && !isEmptyTarget(cPolicy.Spec.NamespaceSelector) {
+				namespaceSelector = cPolicy.Spec.NamespaceSelector.DeepCopy()
+			}
+
+			slog.Info(fmt.Sprintf("found CR %s", customResource.GetName()))
+			objT = append(objT, resource.Object{
+				Unstructured:           *customResource,
+				Source:                 src,
+				ComplianceType:         ot.ComplianceType,
+				MetadataComplianceType: ot.MetadataComplianceType,
+				Policy:                 p.Namespace + "/" + p.Name,
+				Template:               cPolicy.Name,
+				Dependencies:           dependencies,
+				IgnorePending:          cPolicy.template.IgnorePending,
+				NamespaceSelector:      namespaceSelector,
+			})
+		}
+	}
+
+	return objT, diags
+}
@@ -0,0 +1,78 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+	configurationPolicyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+)
+
+func TestExpandPolicies(t *testing.T) {
+	objs, diags := resource.Decode([]byte(`
+apiVersion: policy.open-cluster-management.io/v1
+kind: Policy
+metadata:
+  name: du-policy
+  namespace: ztp-site
+spec:
+  disabled: false
+  policy-templates:
+  - objectDefinition:
+      apiVersion: policy.open-cluster-management.io/v1
+      kind: ConfigurationPolicy
+      metadata:
+        name: du-config
+      spec:
+        remediationAction: inform
+        severity: low
+        object-templates:
+        - complianceType: musthave
+          objectDefinition:
+            apiVersion: v1
+            kind: Namespace
+            metadata:
+              name: openshift-logging
+        - complianceType: mustnothave
+          objectDefinition:
+            apiVersion: v1
+            kind: ConfigMap
+            metadata:
+              name: legacy
+              namespace: openshift-logging
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: plain
+  namespace: default
+`), "policy.yaml")
+	if len(diags) > 0 {
+		t.Fatalf("Decode() diagnostics = %v", diags)
+	}
+
+	got, diags := ExpandPolicies(objs, Options{})
+	if len(diags) > 0 {
+		t.Fatalf("ExpandPolicies() diagnostics = %v", diags)
+	}
+
+	want := []struct {
+		name           string
+		complianceType configurationPolicyv1.ComplianceType
+		source         resource.Source
+	}{
+		{name: "openshift-logging", complianceType: "musthave", source: resource.Source{File: "policy.yaml"}},
+		{name: "legacy", complianceType: "mustnothave", source: resource.Source{File: "policy.yaml"}},
+		{name: "plain", source: resource.Source{File: "policy.yaml", Document: 1}},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("ExpandPolicies() returned %d objects, want %d", len(got), len(want))
+	}
+
+	for i, w := range want {
+		if got[i].GetName() != w.name || got[i].ComplianceType != w.complianceType || got[i].Source != w.source {
+			t.Errorf("ExpandPolicies()[%d] = %s %q %s, want %s %q %s", i,
+				got[i].GetName(), got[i].ComplianceType, got[i].Source, w.name, w.complianceType, w.source)
+		}
+	}
+}
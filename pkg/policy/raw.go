@@ -0,0 +1,90 @@
+package policy
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+
+	configurationPolicyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// templateFuncs are the functions of the policy templates that need no cluster to be evaluated.
+var templateFuncs = template.FuncMap{
+	"base64enc": func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"base64dec": func(s string) (string, error) {
+		decoded, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return "", fmt.Errorf("could not decode base64: %w", err)
+		}
+
+		return string(decoded), nil
+	},
+	"indent": func(spaces int, s string) string {
+		padding := strings.Repeat(" ", spaces)
+
+		return padding + strings.ReplaceAll(s, "\n", "\n"+padding)
+	},
+	"toInt": func(value interface{}) (int, error) {
+		i, err := strconv.Atoi(fmt.Sprint(value))
+		if err != nil {
+			return 0, fmt.Errorf("could not convert to int: %w", err)
+		}
+
+		return i, nil
+	},
+	"toBool": func(value interface{}) (bool, error) {
+		b, err := strconv.ParseBool(fmt.Sprint(value))
+		if err != nil {
+			return false, fmt.Errorf("could not convert to bool: %w", err)
+		}
+
+		return b, nil
+	},
+}
+
+// ReadTemplateValues reads the YAML file holding the data object-templates-raw templates are
+// executed with, such as the ManagedClusterName the policy controller would provide.
+func ReadTemplateValues(file string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not read template values: %w", err)
+	}
+
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("invalid template values %s: %w", file, err)
+	}
+
+	return values, nil
+}
+
+// renderObjectTemplatesRaw executes the hub and managed cluster templates of the
+// object-templates-raw of a ConfigurationPolicy, and decodes the object templates they render.
+// Functions without a local implementation, values missing from the template values and
+// unresolved lookups are reported as errors rather than rendered empty.
+func (r *renderer) renderObjectTemplatesRaw(raw, policyNamespace string) ([]*configurationPolicyv1.ObjectTemplate, error) {
+	rendered := raw
+
+	var err error
+
+	if strings.Contains(rendered, hubDelimLeft) {
+		if rendered, err = r.execute(rendered, hubDelimLeft, hubDelimRight, policyNamespace); err != nil {
+			return nil, err
+		}
+	}
+
+	if rendered, err = r.execute(rendered, "{{", "}}", ""); err != nil {
+		return nil, err
+	}
+
+	var objectTemplates []*configurationPolicyv1.ObjectTemplate
+	if err := yaml.Unmarshal([]byte(rendered), &objectTemplates); err != nil {
+		return nil, fmt.Errorf("rendered template is not a list of object templates: %w", err)
+	}
+
+	return objectTemplates, nil
+}
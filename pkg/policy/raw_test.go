@@ -0,0 +1,127 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+)
+
+func TestExpandPolicies_objectTemplatesRaw(t *testing.T) {
+	values := map[string]interface{}{
+		"ManagedClusterName": "site-1",
+		"namespaces":         []interface{}{"logging", "monitoring"},
+		"ptp":                map[string]interface{}{"enabled": false},
+	}
+
+	type args struct {
+		raw    string
+		values map[string]interface{}
+	}
+
+	tests := []struct {
+		name      string
+		args      args
+		wantNames []string
+		wantDiag  string
+	}{
+		{
+			name: "ranges and conditionals are rendered with the values",
+			args: args{
+				raw: `
+{{- range .namespaces }}
+- complianceType: musthave
+  objectDefinition:
+    apiVersion: v1
+    kind: Namespace
+    metadata:
+      name: {{ . }}
+      labels:
+        cluster: {{ $.ManagedClusterName }}
+{{- end }}
+{{- if .ptp.enabled }}
+- complianceType: musthave
+  objectDefinition:
+    apiVersion: v1
+    kind: Namespace
+    metadata:
+      name: openshift-ptp
+{{- end }}
+`,
+				values: values,
+			},
+			wantNames: []string{"logging", "monitoring"},
+		},
+		{
+			name: "functions without a local implementation are unsupported",
+			args: args{
+				raw:    `{{ protect "secret" }}`,
+				values: values,
+			},
+			wantDiag: "unsupported template construct: function protect",
+		},
+		{
+			name: "hub templates looking up unknown objects are reported",
+			args: args{
+				raw:    `- objectDefinition: {{hub fromConfigMap "" "site" "name" hub}}`,
+				values: values,
+			},
+			wantDiag: "could not find template object: ConfigMap policies/site",
+		},
+		{
+			name: "missing values are reported",
+			args: args{
+				raw: `{{ range .sites }}{{ end }}`,
+			},
+			wantDiag: "could not execute template",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := resource.Object{}
+			policy.Object = map[string]interface{}{
+				"apiVersion": "policy.open-cluster-management.io/v1",
+				"kind":       "Policy",
+				"metadata":   map[string]interface{}{"name": "raw", "namespace": "policies"},
+				"spec": map[string]interface{}{
+					"policy-templates": []interface{}{map[string]interface{}{
+						"objectDefinition": map[string]interface{}{
+							"apiVersion": "policy.open-cluster-management.io/v1",
+							"kind":       "ConfigurationPolicy",
+							"metadata":   map[string]interface{}{"name": "raw-config"},
+							"spec":       map[string]interface{}{"object-templates-raw": tt.args.raw},
+						},
+					}},
+				},
+			}
+
+			got, diags := ExpandPolicies([]resource.Object{policy}, Options{TemplateValues: tt.args.values})
+
+			if tt.wantDiag != "" {
+				if len(diags) != 1 || !strings.Contains(diags[0].Reason, tt.wantDiag) {
+					t.Errorf("ExpandPolicies() diagnostics = %v, want %q", diags, tt.wantDiag)
+				}
+
+				return
+			}
+
+			if len(diags) > 0 {
+				t.Fatalf("ExpandPolicies() diagnostics = %v", diags)
+			}
+
+			var names []string
+			for _, o := range got {
+				names = append(names, o.GetName())
+
+				if o.GetLabels()["cluster"] != "site-1" {
+					t.Errorf("ExpandPolicies() labels of %s = %v", o.GetName(), o.GetLabels())
+				}
+			}
+
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("ExpandPolicies() = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	hubDelimLeft  = "{{hub"
+	hubDelimRight = "hub}}"
+
+	// Wildcard replaces the templates that cannot be rendered when Options.TemplateWildcards is set.
+	// It is a placeholder of the compare package, so it matches any resource value.
+	Wildcard = "{{ .unresolved }}"
+)
+
+var (
+	errUnsupportedTemplate    = errors.New("unsupported template construct")
+	errTemplateObjectNotFound = errors.New("could not find template object")
+)
+
+var (
+	// undefinedFunction extracts the function name from the text/template parse error of a
+	// function without a local implementation.
+	undefinedFunction = regexp.MustCompile(`function "([^"]+)" not defined`)
+	// templateAction finds the hub and managed cluster templates of a value.
+	templateAction = regexp.MustCompile(`(?s)\{\{hub.*?hub\}\}|\{\{.*?\}\}`)
+	// fieldAction finds the templates that only reference a field, such as {{ .ManagedClusterName }}
+	// or the placeholders of the compare package.
+	fieldAction = regexp.MustCompile(`^\{\{-?\s*\.`)
+)
+
+// renderer evaluates the hub and managed cluster templates of policies offline, looking objects up
+// in the template objects that stand in for the hub and the managed cluster.
+type renderer struct {
+	options Options
+}
+
+func newRenderer(options Options) *renderer {
+	return &renderer{options: options}
+}
+
+// funcs returns the template functions, resolving empty namespaces of lookups to defaultNamespace
+// as the hub does with the namespace of the policy.
+func (r *renderer) funcs(defaultNamespace string) template.FuncMap {
+	funcs := template.FuncMap{}
+	for name, f := range templateFuncs {
+		funcs[name] = f
+	}
+
+	namespaceOrDefault := func(namespace string) string {
+		if namespace == "" {
+			return defaultNamespace
+		}
+
+		return namespace
+	}
+
+	funcs["fromConfigMap"] = func(namespace, name, key string) (string, error) {
+		return r.dataValue("ConfigMap", namespaceOrDefault(namespace), name, key)
+	}
+	funcs["fromSecret"] = func(namespace, name, key string) (string, error) {
+		return r.dataValue("Secret", namespaceOrDefault(namespace), name, key)
+	}
+	funcs["fromClusterClaim"] = r.fromClusterClaim
+	funcs["lookup"] = func(apiVersion, kind, namespace, name string) (map[string]interface{}, error) {
+		return r.lookup(apiVersion, kind, namespaceOrDefault(namespace), name)
+	}
+
+	return funcs
+}
+
+func (r *renderer) find(gk schema.GroupKind, namespace, name string) []resource.Object {
+	var found []resource.Object
+
+	for _, o := range r.options.TemplateObjects {
+		if o.GroupVersionKind().GroupKind() == gk && o.GetNamespace() == namespace && (name == "" || o.GetName() == name) {
+			found = append(found, o)
+		}
+	}
+
+	return found
+}
+
+// dataValue returns a value of the data of a ConfigMap or Secret. Like on a cluster, Secret values
+// are base64 encoded.
+func (r *renderer) dataValue(kind, namespace, name, key string) (string, error) {
+	found := r.find(schema.GroupKind{Kind: kind}, namespace, name)
+	if len(found) == 0 {
+		return "", fmt.Errorf("%w: %s %s/%s", errTemplateObjectNotFound, kind, namespace, name)
+	}
+
+	if value, ok, _ := unstructured.NestedString(found[0].Object, "data", key); ok {
+		return value, nil
+	}
+
+	if value, ok, _ := unstructured.NestedString(found[0].Object, "stringData", key); ok && kind == "Secret" {
+		return base64.StdEncoding.EncodeToString([]byte(value)), nil
+	}
+
+	return "", fmt.Errorf("%w: key %s of %s %s/%s", errTemplateObjectNotFound, key, kind, namespace, name)
+}
+
+func (r *renderer) fromClusterClaim(name string) (string, error) {
+	found := r.find(schema.GroupKind{Group: "cluster.open-cluster-management.io", Kind: "ClusterClaim"}, "", name)
+	if len(found) == 0 {
+		return "", fmt.Errorf("%w: ClusterClaim %s", errTemplateObjectNotFound, name)
+	}
+
+	value, _, _ := unstructured.NestedString(found[0].Object, "spec", "value")
+
+	return value, nil
+}
+
+// lookup returns the object, or a list of the objects of the namespace when name is empty. Like on
+// a cluster, an object that does not exist is an empty map.
+func (r *renderer) lookup(apiVersion, kind, namespace, name string) (map[string]interface{}, error) {
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid apiVersion %q: %w", apiVersion, err)
+	}
+
+	found := r.find(gv.WithKind(kind).GroupKind(), namespace, name)
+
+	if name == "" {
+		items := make([]interface{}, 0, len(found))
+		for _, o := range found {
+			items = append(items, o.Object)
+		}
+
+		return map[string]interface{}{"items": items}, nil
+	}
+
+	if len(found) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	return found[0].Object, nil
+}
+
+// execute renders the templates of text delimited by left and right.
+func (r *renderer) execute(text, left, right, defaultNamespace string) (string, error) {
+	tmpl, err := template.New("policy").Delims(left, right).Funcs(r.funcs(defaultNamespace)).Option("missingkey=error").Parse(text)
+	if err != nil {
+		if match := undefinedFunction.FindStringSubmatch(err.Error()); match != nil {
+			return "", fmt.Errorf("%w: function %s", errUnsupportedTemplate, match[1])
+		}
+
+		return "", fmt.Errorf("could not parse template: %w", err)
+	}
+
+	rendered := &bytes.Buffer{}
+	if err := tmpl.Execute(rendered, r.options.TemplateValues); err != nil {
+		return "", fmt.Errorf("could not execute template: %w", err)
+	}
+
+	return rendered.String(), nil
+}
+
+// renderString renders the hub templates of a value, and then its managed cluster templates. A
+// value whose templates only reference fields that are not in the template values is left as is,
+// since the compare package matches such placeholders with any value. Templates that cannot be
+// rendered are replaced with the Wildcard when Options.TemplateWildcards is set.
+func (r *renderer) renderString(value, policyNamespace string) (interface{}, error) {
+	rendered := value
+
+	var err error
+
+	if strings.Contains(rendered, hubDelimLeft) {
+		rendered, err = r.execute(rendered, hubDelimLeft, hubDelimRight, policyNamespace)
+	}
+
+	if err == nil && strings.Contains(rendered, "{{") {
+		var clusterRendered string
+
+		clusterRendered, err = r.execute(rendered, "{{", "}}", "")
+		if err == nil {
+			rendered = clusterRendered
+		} else if onlyFieldActions(rendered) {
+			err = nil
+		}
+	}
+
+	if err != nil {
+		if !r.options.TemplateWildcards {
+			return value, err
+		}
+
+		return templateAction.ReplaceAllStringFunc(value, func(action string) string {
+			if fieldAction.MatchString(action) {
+				return action
+			}
+
+			return Wildcard
+		}), nil
+	}
+
+	return typedValue(value, rendered), nil
+}
+
+func onlyFieldActions(value string) bool {
+	for _, action := range templateAction.FindAllString(value, -1) {
+		if !fieldAction.MatchString(action) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// typedValue converts the rendered value of a single template ending with toInt or toBool, as the
+// policy controllers do to set numbers and booleans from templates.
+func typedValue(value, rendered string) interface{} {
+	trimmed := strings.TrimSpace(value)
+	if templateAction.FindString(trimmed) != trimmed {
+		return rendered
+	}
+
+	switch {
+	case strings.Contains(trimmed, "toInt"):
+		if i, err := strconv.ParseInt(rendered, 10, 64); err == nil {
+			return i
+		}
+	case strings.Contains(trimmed, "toBool"):
+		if b, err := strconv.ParseBool(rendered); err == nil {
+			return b
+		}
+	}
+
+	return rendered
+}
+
+// renderObject renders the templates of every string of an object in place, and returns the
+// errors of the values that could not be rendered.
+func (r *renderer) renderObject(obj interface{}, policyNamespace string) []error {
+	var errs []error
+
+	render := func(value interface{}) interface{} {
+		s, ok := value.(string)
+		if !ok || !strings.Contains(s, "{{") {
+			errs = append(errs, r.renderObject(value, policyNamespace)...)
+
+			return value
+		}
+
+		rendered, err := r.renderString(s, policyNamespace)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%q: %w", s, err))
+		}
+
+		return rendered
+	}
+
+	switch typed := obj.(type) {
+	case map[string]interface{}:
+		for k, v := range typed {
+			typed[k] = render(v)
+		}
+	case []interface{}:
+		for i, v := range typed {
+			typed[i] = render(v)
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,98 @@
+package policy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+)
+
+func Test_renderer_renderObject(t *testing.T) {
+	templateObjects, diags := resource.Decode([]byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: site-config
+  namespace: policies
+data:
+  interface: ens5f0
+  replicas: "3"
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: pull-secret
+  namespace: openshift-config
+stringData:
+  token: abc
+---
+apiVersion: cluster.open-cluster-management.io/v1alpha1
+kind: ClusterClaim
+metadata:
+  name: id.openshift.io
+spec:
+  value: 0b7a5c9e
+`), "hub.yaml")
+	if len(diags) > 0 {
+		t.Fatalf("Decode() diagnostics = %v", diags)
+	}
+
+	tests := []struct {
+		name      string
+		wildcards bool
+		object    map[string]interface{}
+		want      map[string]interface{}
+		wantErr   string
+	}{
+		{
+			name: "hub and managed cluster templates are rendered from the template objects",
+			object: map[string]interface{}{
+				"interface": `{{hub fromConfigMap "" "site-config" "interface" hub}}`,
+				"replicas":  `{{hub fromConfigMap "" "site-config" "replicas" | toInt hub}}`,
+				"token":     `{{ fromSecret "openshift-config" "pull-secret" "token" }}`,
+				"cluster":   []interface{}{`id-{{ fromClusterClaim "id.openshift.io" }}`},
+				"kind":      `{{ (lookup "v1" "ConfigMap" "policies" "site-config").kind }}`,
+			},
+			want: map[string]interface{}{
+				"interface": "ens5f0",
+				"replicas":  int64(3),
+				"token":     "YWJj",
+				"cluster":   []interface{}{"id-0b7a5c9e"},
+				"kind":      "ConfigMap",
+			},
+		},
+		{
+			name:    "unresolved templates are reported",
+			object:  map[string]interface{}{"interface": `{{hub fromConfigMap "" "unknown" "interface" hub}}`},
+			want:    map[string]interface{}{"interface": `{{hub fromConfigMap "" "unknown" "interface" hub}}`},
+			wantErr: "could not find template object: ConfigMap policies/unknown",
+		},
+		{
+			name:      "unresolved templates are wildcards",
+			wildcards: true,
+			object:    map[string]interface{}{"name": `du-{{hub fromConfigMap "" "unknown" "name" hub}}`},
+			want:      map[string]interface{}{"name": "du-" + Wildcard},
+		},
+		{
+			name:   "placeholders are left as is",
+			object: map[string]interface{}{"isolated": `{{ .spec.cpu.isolated | regex "[0-9,-]+" }}`},
+			want:   map[string]interface{}{"isolated": `{{ .spec.cpu.isolated | regex "[0-9,-]+" }}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRenderer(Options{TemplateObjects: templateObjects, TemplateWildcards: tt.wildcards})
+
+			errs := r.renderObject(tt.object, "policies")
+			if tt.wantErr == "" && len(errs) > 0 || tt.wantErr != "" && (len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.wantErr)) {
+				t.Errorf("renderObject() errors = %v, want %q", errs, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(tt.object, tt.want) {
+				t.Errorf("renderObject() = %v, want %v", tt.object, tt.want)
+			}
+		})
+	}
+}
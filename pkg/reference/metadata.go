@@ -0,0 +1,370 @@
+// Package reference reads reference directories along with the metadata describing which of their
+// CRs are required, and evaluates a comparison against that metadata.
+package reference
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/openshift-kni/reference-validator/pkg/compare"
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+	"github.com/openshift-kni/reference-validator/pkg/util"
+	"sigs.k8s.io/yaml"
+)
+
+// MetadataFile is the name of the metadata file at the root of a reference directory.
+const MetadataFile = "metadata.yaml"
+
+// ComponentType tells whether a component must be found in the resources.
+type ComponentType string
+
+const (
+	ComponentRequired ComponentType = "required"
+	ComponentOptional ComponentType = "optional"
+)
+
+// Metadata groups the CRs of a reference into parts and components.
+type Metadata struct {
+	Parts []Part `json:"parts"`
+}
+
+// Part is a named group of components, such as the components of an operator.
+type Part struct {
+	Name       string      `json:"name"`
+	Components []Component `json:"components"`
+}
+
+// Component is a set of reference files that are either all expected in the resources or, when
+// optional, not at all. A component either lists its files or "one of" alternatives, such as an
+// ordinary clock or a boundary clock, of which exactly one is expected.
+type Component struct {
+	Name string `json:"name"`
+	// Type defaults to required.
+	Type  ComponentType `json:"type,omitempty"`
+	Files []string      `json:"files,omitempty"`
+	OneOf []Alternative `json:"oneOf,omitempty"`
+}
+
+// Alternative is one of the sets of files that can satisfy a component.
+type Alternative struct {
+	Name  string   `json:"name"`
+	Files []string `json:"files"`
+}
+
+var errFileNotFound = errors.New("could not find file")
+
+// ReadMetadata reads the metadata file at the root of every directory, archive and git source that
+// has one, resolving the files it lists relative to their root. The standard input is a single
+// stream without files, so it has no metadata.
+func ReadMetadata(dirs []string) (Metadata, error) {
+	metadata := Metadata{}
+
+	for _, dir := range dirs {
+		if dir == resource.Stdin {
+			continue
+		}
+
+		file := filepath.Join(dir, MetadataFile)
+
+		data, exists, err := readMetadataFile(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return Metadata{}, fmt.Errorf("could not read reference metadata: %w", err)
+		}
+
+		dirMetadata := Metadata{}
+		if err := yaml.UnmarshalStrict(data, &dirMetadata); err != nil {
+			return Metadata{}, fmt.Errorf("invalid reference metadata %s: %w", file, err)
+		}
+
+		if err := dirMetadata.resolve(dir, exists); err != nil {
+			return Metadata{}, fmt.Errorf("invalid reference metadata %s: %w", file, err)
+		}
+
+		metadata.Parts = append(metadata.Parts, dirMetadata.Parts...)
+	}
+
+	return metadata, nil
+}
+
+// readMetadataFile returns the content of the metadata file of a directory, archive or git source,
+// along with a function telling whether a file of it exists, or os.ErrNotExist when there is none.
+func readMetadataFile(dir string) ([]byte, func(file string) bool, error) {
+	file := filepath.Join(dir, MetadataFile)
+
+	if util.IsDirectory(dir) {
+		data, err := os.ReadFile(file)
+
+		return data, func(f string) bool {
+			_, err := os.Stat(f)
+
+			return err == nil
+		}, err
+	}
+
+	var (
+		data    []byte
+		found   bool
+		readErr error
+	)
+
+	files := map[string]bool{}
+
+	err := resource.WalkSource(dir, func(name string, r io.Reader) {
+		files[name] = true
+
+		if name == file {
+			found = true
+			data, readErr = io.ReadAll(r)
+		}
+	})
+	if err == nil {
+		err = readErr
+	}
+
+	if err == nil && !found {
+		err = fmt.Errorf("%w: %s", os.ErrNotExist, file)
+	}
+
+	return data, func(f string) bool { return files[f] }, err
+}
+
+// resolve validates the metadata and joins the files it lists with dir, checking that they exist.
+func (m *Metadata) resolve(dir string, exists func(file string) bool) error {
+	for i := range m.Parts {
+		part := &m.Parts[i]
+		if part.Name == "" {
+			return fmt.Errorf("part %d has no name", i)
+		}
+
+		for j := range part.Components {
+			component := &part.Components[j]
+			if component.Name == "" {
+				return fmt.Errorf("component %d of part %s has no name", j, part.Name)
+			}
+
+			if err := component.resolve(dir, exists); err != nil {
+				return fmt.Errorf("component %s/%s: %w", part.Name, component.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *Component) resolve(dir string, exists func(file string) bool) error {
+	switch c.Type {
+	case "":
+		c.Type = ComponentRequired
+	case ComponentRequired, ComponentOptional:
+	default:
+		return fmt.Errorf("unsupported type %q, must be one of: %s, %s", c.Type, ComponentRequired, ComponentOptional)
+	}
+
+	if (len(c.Files) == 0) == (len(c.OneOf) == 0) {
+		return errors.New("must list either files or oneOf alternatives")
+	}
+
+	var err error
+
+	if c.Files, err = resolveFiles(dir, c.Files, exists); err != nil {
+		return err
+	}
+
+	for i := range c.OneOf {
+		alternative := &c.OneOf[i]
+		if alternative.Name == "" || len(alternative.Files) == 0 {
+			return fmt.Errorf("alternative %d must have a name and files", i)
+		}
+
+		if alternative.Files, err = resolveFiles(dir, alternative.Files, exists); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func resolveFiles(dir string, files []string, exists func(file string) bool) ([]string, error) {
+	resolved := make([]string, 0, len(files))
+
+	for _, f := range files {
+		path := filepath.Join(dir, f)
+		if !exists(path) {
+			return nil, fmt.Errorf("%w %s", errFileNotFound, f)
+		}
+
+		resolved = append(resolved, path)
+	}
+
+	return resolved, nil
+}
+
+// ReadDirs reads every reference CR found under the given directories, archives, git sources or
+// standard input, leaving out their metadata file, see ReadMetadata.
+func ReadDirs(dirs []string) ([]resource.Object, []resource.Diagnostic) {
+	var (
+		objects []resource.Object
+		diags   []resource.Diagnostic
+	)
+
+	for _, d := range dirs {
+		if !util.IsDirectory(d) {
+			streamObjects, streamDiags := resource.ReadDirs([]string{d})
+			objects = append(objects, streamObjects...)
+
+			// the metadata file does not decode to any object, only to a diagnostic
+			for _, diag := range streamDiags {
+				if diag.File != filepath.Join(d, MetadataFile) {
+					diags = append(diags, diag)
+				}
+			}
+
+			continue
+		}
+
+		files, err := util.GetFileNames(d)
+		if err != nil {
+			diags = append(diags, resource.Diagnostic{File: d, Document: -1, Reason: err.Error()})
+		}
+
+		for _, f := range files {
+			if f == filepath.Join(d, MetadataFile) {
+				continue
+			}
+
+			fileObjects, fileDiags := resource.ReadFile(f)
+			objects = append(objects, fileObjects...)
+			diags = append(diags, fileDiags...)
+		}
+	}
+
+	return objects, diags
+}
+
+// Evaluate reports whether each component of the metadata is found in the result of a comparison.
+// A component is found when at least one of its reference CRs has a resource, and conflicts when
+// several of its "one of" alternatives are found. The missing CRs of
+// optional components that are not found, and of "one of" alternatives that are not used, are
+// left out of the result since they are not expected.
+func (m Metadata) Evaluate(result compare.Result) compare.Result {
+	if len(m.Parts) == 0 {
+		return result
+	}
+
+	notExpected := map[string]bool{}
+
+	for _, part := range m.Parts {
+		for _, c := range part.Components {
+			component := compare.ComponentResult{
+				Part:     part.Name,
+				Name:     c.Name,
+				Required: c.Type == ComponentRequired,
+				Status:   compare.ComponentPresent,
+			}
+
+			if len(c.OneOf) == 0 {
+				if !found(result, c.Files) {
+					component.Status = componentNotFound(component.Required)
+				}
+
+				if component.Status == compare.ComponentSkipped {
+					markNotExpected(notExpected, c.Files)
+				}
+
+				result.Components = append(result.Components, component)
+
+				continue
+			}
+
+			evaluateOneOf(result, c, &component, notExpected)
+
+			result.Components = append(result.Components, component)
+		}
+	}
+
+	objects := make([]compare.ObjectResult, 0, len(result.Objects))
+
+	for _, o := range result.Objects {
+		if o.Status == compare.StatusMissing && notExpected[o.Reference.File] {
+			continue
+		}
+
+		objects = append(objects, o)
+	}
+
+	result.Objects = objects
+	result.Summarize()
+
+	return result
+}
+
+// evaluateOneOf sets the alternative of the component found in the result, or the alternatives
+// found when there are several, which conflict. The alternatives that are not found are not
+// expected, whether another alternative is found or not.
+func evaluateOneOf(result compare.Result, c Component, component *compare.ComponentResult, notExpected map[string]bool) {
+	var (
+		foundAlternatives []string
+		names             []string
+	)
+
+	for _, alternative := range c.OneOf {
+		names = append(names, alternative.Name)
+
+		if found(result, alternative.Files) {
+			foundAlternatives = append(foundAlternatives, alternative.Name)
+
+			continue
+		}
+
+		markNotExpected(notExpected, alternative.Files)
+	}
+
+	switch len(foundAlternatives) {
+	case 0:
+		component.Status = componentNotFound(component.Required)
+		component.Alternatives = names
+	case 1:
+		component.Alternative = foundAlternatives[0]
+	default:
+		component.Status = compare.ComponentConflicting
+		component.Alternatives = foundAlternatives
+	}
+}
+
+func componentNotFound(required bool) compare.ComponentStatus {
+	if required {
+		return compare.ComponentMissing
+	}
+
+	return compare.ComponentSkipped
+}
+
+func markNotExpected(notExpected map[string]bool, files []string) {
+	for _, f := range files {
+		notExpected[f] = true
+	}
+}
+
+// found is true when a reference CR read from one of the files has a resource.
+func found(result compare.Result, files []string) bool {
+	for _, o := range result.Objects {
+		if o.Reference == nil || (o.Status != compare.StatusMatched && o.Status != compare.StatusDifferent) {
+			continue
+		}
+
+		for _, f := range files {
+			if o.Reference.File == f {
+				return true
+			}
+		}
+	}
+
+	return false
+}
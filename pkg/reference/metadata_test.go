@@ -0,0 +1,260 @@
+package reference
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/openshift-kni/reference-validator/pkg/compare"
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+)
+
+func mustWriteTestDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("could not write test file: %v", err)
+		}
+	}
+
+	return dir
+}
+
+func configMap(name string) string {
+	return "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: " + name + "\n  namespace: ns1\n"
+}
+
+func TestReadMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata string
+		wantErr  string
+	}{
+		{
+			name: "files and alternatives",
+			metadata: `
+parts:
+- name: ptp
+  components:
+  - name: operator
+    files: [operator.yaml]
+  - name: clock
+    type: optional
+    oneOf:
+    - name: ordinary
+      files: [ordinary.yaml]
+`,
+		},
+		{
+			name:     "unsupported type",
+			metadata: "parts:\n- name: ptp\n  components:\n  - name: operator\n    type: maybe\n    files: [operator.yaml]\n",
+			wantErr:  `component ptp/operator: unsupported type "maybe"`,
+		},
+		{
+			name:     "neither files nor alternatives",
+			metadata: "parts:\n- name: ptp\n  components:\n  - name: operator\n",
+			wantErr:  "must list either files or oneOf alternatives",
+		},
+		{
+			name:     "unknown file",
+			metadata: "parts:\n- name: ptp\n  components:\n  - name: operator\n    files: [unknown.yaml]\n",
+			wantErr:  "could not find file unknown.yaml",
+		},
+		{
+			name:     "unknown field",
+			metadata: "parts:\n- name: ptp\n  component: []\n",
+			wantErr:  `unknown field "component"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := mustWriteTestDir(t, map[string]string{
+				MetadataFile:    tt.metadata,
+				"operator.yaml": configMap("operator"),
+				"ordinary.yaml": configMap("ordinary"),
+			})
+
+			got, err := ReadMetadata([]string{dir})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ReadMetadata() error = %v, want %q", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ReadMetadata() error = %v", err)
+			}
+
+			want := []string{filepath.Join(dir, "operator.yaml")}
+			if files := got.Parts[0].Components[0].Files; !reflect.DeepEqual(files, want) {
+				t.Errorf("ReadMetadata() files = %v, want %v", files, want)
+			}
+
+			if got.Parts[0].Components[0].Type != ComponentRequired {
+				t.Errorf("ReadMetadata() type = %s, want %s", got.Parts[0].Components[0].Type, ComponentRequired)
+			}
+		})
+	}
+}
+
+func TestMetadata_Evaluate(t *testing.T) {
+	dir := mustWriteTestDir(t, map[string]string{
+		MetadataFile: `
+parts:
+- name: ptp
+  components:
+  - name: operator
+    files: [operator.yaml]
+  - name: clock
+    oneOf:
+    - name: ordinary
+      files: [ordinary.yaml]
+    - name: boundary
+      files: [boundary.yaml]
+- name: logging
+  components:
+  - name: forwarder
+    type: optional
+    files: [forwarder.yaml]
+  - name: storage
+    files: [storage.yaml]
+`,
+		"operator.yaml":  configMap("operator"),
+		"ordinary.yaml":  configMap("ordinary"),
+		"boundary.yaml":  configMap("boundary"),
+		"forwarder.yaml": configMap("forwarder"),
+		"storage.yaml":   configMap("storage"),
+	})
+
+	metadata, err := ReadMetadata([]string{dir})
+	if err != nil {
+		t.Fatalf("ReadMetadata() error = %v", err)
+	}
+
+	references, diags := ReadDirs([]string{dir})
+	if len(diags) > 0 {
+		t.Fatalf("ReadDirs() diagnostics = %v", diags)
+	}
+
+	resources, _ := resource.Decode([]byte(configMap("operator")+"---\n"+configMap("boundary")), "resources.yaml")
+
+	result := metadata.Evaluate(compare.NewComparator(compare.Config{}).Compare(references, resources))
+
+	wantComponents := []compare.ComponentResult{
+		{Part: "ptp", Name: "operator", Required: true, Status: compare.ComponentPresent},
+		{Part: "ptp", Name: "clock", Required: true, Status: compare.ComponentPresent, Alternative: "boundary"},
+		{Part: "logging", Name: "forwarder", Status: compare.ComponentSkipped},
+		{Part: "logging", Name: "storage", Required: true, Status: compare.ComponentMissing},
+	}
+	if !reflect.DeepEqual(result.Components, wantComponents) {
+		t.Errorf("Evaluate() components = %v, want %v", result.Components, wantComponents)
+	}
+
+	var missing []string
+
+	for _, o := range result.Objects {
+		if o.Status == compare.StatusMissing {
+			missing = append(missing, o.Name)
+		}
+	}
+
+	if !reflect.DeepEqual(missing, []string{"storage"}) {
+		t.Errorf("Evaluate() missing = %v, want [storage]", missing)
+	}
+
+	if result.Passed() || result.Summary.MissingComponents != 1 {
+		t.Errorf("Evaluate() summary = %s, want 1 missing component", result.Summary)
+	}
+}
+
+func TestMetadata_Evaluate_conflicting(t *testing.T) {
+	dir := mustWriteTestDir(t, map[string]string{
+		MetadataFile: `
+parts:
+- name: ptp
+  components:
+  - name: clock
+    oneOf:
+    - name: ordinary
+      files: [ordinary.yaml]
+    - name: boundary
+      files: [boundary.yaml]
+`,
+		"ordinary.yaml": configMap("ordinary"),
+		"boundary.yaml": configMap("boundary"),
+	})
+
+	metadata, err := ReadMetadata([]string{dir})
+	if err != nil {
+		t.Fatalf("ReadMetadata() error = %v", err)
+	}
+
+	references, _ := ReadDirs([]string{dir})
+	resources, _ := resource.Decode([]byte(configMap("ordinary")+"---\n"+configMap("boundary")), "resources.yaml")
+
+	result := metadata.Evaluate(compare.NewComparator(compare.Config{}).Compare(references, resources))
+
+	wantComponents := []compare.ComponentResult{
+		{Part: "ptp", Name: "clock", Required: true, Status: compare.ComponentConflicting, Alternatives: []string{"ordinary", "boundary"}},
+	}
+	if !reflect.DeepEqual(result.Components, wantComponents) {
+		t.Errorf("Evaluate() components = %v, want %v", result.Components, wantComponents)
+	}
+
+	if result.Passed() || result.Summary.ConflictingComponents != 1 {
+		t.Errorf("Evaluate() summary = %s, want 1 conflicting component", result.Summary)
+	}
+}
+
+func TestReadMetadata_archive(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "reference.tar")
+
+	buf := &bytes.Buffer{}
+	w := tar.NewWriter(buf)
+
+	for name, content := range map[string]string{
+		MetadataFile:    "parts:\n- name: ptp\n  components:\n  - name: operator\n    files: [operator.yaml]\n",
+		"operator.yaml": configMap("operator"),
+	} {
+		if err := w.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(archive, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadMetadata([]string{archive})
+	if err != nil {
+		t.Fatalf("ReadMetadata() error = %v", err)
+	}
+
+	want := []string{filepath.Join(archive, "operator.yaml")}
+	if len(got.Parts) != 1 || !reflect.DeepEqual(got.Parts[0].Components[0].Files, want) {
+		t.Errorf("ReadMetadata() = %v, want the component of %s", got, want)
+	}
+
+	references, diags := ReadDirs([]string{archive})
+	if len(diags) > 0 || len(references) != 1 || references[0].Source.File != want[0] {
+		t.Errorf("ReadDirs() = %v, diagnostics = %v, want the operator read from %s", references, diags, want[0])
+	}
+}
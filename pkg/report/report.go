@@ -0,0 +1,176 @@
+// Package report renders the result of a comparison in the supported output formats.
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/openshift-kni/reference-validator/pkg/compare"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+	FormatJUnit = "junit"
+	FormatDiff  = "diff"
+)
+
+var Formats = []string{FormatText, FormatJSON, FormatYAML, FormatJUnit, FormatDiff}
+
+// Write serializes the result in the requested output format. color only applies to the
+// human-readable diff format.
+func Write(out io.Writer, rep compare.Result, format string, color bool) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(rep); err != nil {
+			return fmt.Errorf("could not write JSON report: %w", err)
+		}
+	case FormatYAML:
+		yamlBytes, err := yaml.Marshal(rep)
+		if err != nil {
+			return fmt.Errorf("could not write YAML report: %w", err)
+		}
+
+		if _, err := out.Write(yamlBytes); err != nil {
+			return fmt.Errorf("could not write YAML report: %w", err)
+		}
+	case FormatJUnit:
+		return writeJUnitReport(out, rep)
+	case FormatDiff:
+		return writeUnifiedDiff(out, rep, color)
+	default:
+		writeText(out, rep)
+	}
+
+	return nil
+}
+
+func writeText(out io.Writer, rep compare.Result) {
+	for _, o := range rep.Objects {
+		fmt.Fprintf(out, "%s: %s\n", o.Status, o.ID())
+
+		for _, d := range o.Differences {
+			fmt.Fprintf(out, "  %s\n", d)
+		}
+
+		for _, b := range o.BlockedBy {
+			fmt.Fprintf(out, "  blocked: %s\n", b)
+		}
+	}
+
+	if len(rep.Components) > 0 {
+		fmt.Fprintln(out)
+	}
+
+	for _, c := range rep.Components {
+		fmt.Fprintln(out, c)
+	}
+
+	fmt.Fprintf(out, "\n%s\n", rep.Summary)
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnitReport renders one test case per object and per component so CI systems can display
+// each CR as a test.
+func writeJUnitReport(out io.Writer, rep compare.Result) error {
+	suite := junitTestSuite{Name: "reference-validator", Tests: len(rep.Objects)}
+
+	for _, o := range rep.Objects {
+		testCase := junitTestCase{Name: o.ID(), ClassName: o.Kind}
+
+		if !o.Status.Passed() {
+			suite.Failures++
+
+			var text strings.Builder
+			if o.Reference != nil {
+				fmt.Fprintf(&text, "reference: %s\n", o.Reference)
+			}
+
+			if o.Resource != nil {
+				fmt.Fprintf(&text, "resource: %s\n", o.Resource)
+			}
+
+			for _, d := range o.Differences {
+				fmt.Fprintf(&text, "%s\n", d)
+			}
+
+			for _, b := range o.BlockedBy {
+				fmt.Fprintf(&text, "blocked: %s\n", b)
+			}
+
+			testCase.Failure = &junitFailure{
+				Message: fmt.Sprintf("%s is %s", o.ID(), o.Status),
+				Type:    string(o.Status),
+				Text:    text.String(),
+			}
+		}
+
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	for _, c := range rep.Components {
+		suite.Tests++
+
+		testCase := junitTestCase{Name: c.ID(), ClassName: "Component"}
+
+		if c.Status == compare.ComponentMissing || c.Status == compare.ComponentConflicting {
+			suite.Failures++
+
+			testCase.Failure = &junitFailure{Message: c.String(), Type: string(c.Status)}
+		}
+
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	suites := junitTestSuites{Suites: []junitTestSuite{suite}, Tests: suite.Tests, Failures: suite.Failures}
+
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return fmt.Errorf("could not write JUnit report: %w", err)
+	}
+
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(suites); err != nil {
+		return fmt.Errorf("could not write JUnit report: %w", err)
+	}
+
+	if _, err := io.WriteString(out, "\n"); err != nil {
+		return fmt.Errorf("could not write JUnit report: %w", err)
+	}
+
+	return nil
+}
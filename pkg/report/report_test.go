@@ -0,0 +1,86 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/openshift-kni/reference-validator/pkg/compare"
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newTestObject(apiVersion, kind, namespace, name string) resource.Object {
+	u := unstructured.Unstructured{}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+
+	return resource.Object{Unstructured: u}
+}
+
+func TestWrite(t *testing.T) {
+	reference := newTestObject("v1", "ConfigMap", "ns1", "cm")
+	reference.Source = resource.Source{File: "reference.yaml"}
+	res := newTestObject("v1", "ConfigMap", "ns1", "cm")
+	res.Source = resource.Source{File: "resource.yaml", Document: 1}
+	res.Object["data"] = map[string]interface{}{"key": "value"}
+
+	comparator := compare.NewComparator(compare.Config{Match: compare.MatchExact})
+	rep := comparator.Compare([]resource.Object{reference, newTestObject("v1", "Namespace", "", "ns1")}, []resource.Object{res})
+
+	tests := []struct {
+		name   string
+		format string
+		want   []string
+	}{
+		{
+			name:   "text",
+			format: FormatText,
+			want:   []string{"different: v1/ConfigMap ns1/cm", "+ .data: map[key:value]", "1 different, 1 missing"},
+		},
+		{
+			name:   "yaml",
+			format: FormatYAML,
+			want:   []string{"apiVersion: " + compare.ResultAPIVersion, "file: resource.yaml", "path: .data"},
+		},
+		{
+			name:   "junit",
+			format: FormatJUnit,
+			want:   []string{`<testsuites tests="2" failures="2">`, `type="missing"`, "resource: resource.yaml#1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			if err := Write(out, rep, tt.format, false); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("Write() = %s, want it to contain %q", out.String(), want)
+				}
+			}
+		})
+	}
+
+	t.Run("json round trip", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		if err := Write(out, rep, FormatJSON, false); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+
+		got := compare.Result{}
+		if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+			t.Fatalf("could not decode JSON report: %v", err)
+		}
+
+		if got.Summary != rep.Summary || len(got.Objects) != len(rep.Objects) {
+			t.Errorf("Write() = %+v, want %+v", got, rep)
+		}
+	})
+}
@@ -0,0 +1,195 @@
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/openshift-kni/reference-validator/pkg/compare"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	k8sdiff "k8s.io/kubectl/pkg/cmd/diff"
+	"k8s.io/utils/exec"
+)
+
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+var ColorModes = []string{ColorAuto, ColorAlways, ColorNever}
+
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiCyan  = "\x1b[36m"
+)
+
+// UseColor resolves a color mode, coloring in auto mode only when writing to a terminal.
+func UseColor(mode string, out io.Writer) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+
+	f, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+
+	fileInfo, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return fileInfo.Mode()&os.ModeCharDevice != 0
+}
+
+// writeUnifiedDiff prints a kubectl diff style unified diff of every reference that differs from
+// its resource. The diff program can be replaced through KUBECTL_EXTERNAL_DIFF like with kubectl.
+func writeUnifiedDiff(out io.Writer, rep compare.Result, color bool) error {
+	for _, o := range rep.Objects {
+		switch o.Status {
+		case compare.StatusMatched, compare.StatusAbsent:
+			continue
+		case compare.StatusDifferent:
+			writeDiffHeader(out, o, color)
+
+			diff, err := runDiffProgram(o)
+			if err != nil {
+				return err
+			}
+
+			if color {
+				if diff, err = colorizeDiff(diff); err != nil {
+					return err
+				}
+			}
+
+			if _, err := out.Write(diff); err != nil {
+				return fmt.Errorf("could not write diff: %w", err)
+			}
+		case compare.StatusMissing, compare.StatusUnexpected, compare.StatusForbidden:
+			writeDiffHeader(out, o, color)
+		}
+
+		fmt.Fprintln(out)
+	}
+
+	for _, c := range rep.Components {
+		if c.Status == compare.ComponentMissing || c.Status == compare.ComponentConflicting {
+			fmt.Fprintf(out, "# %s\n\n", c)
+		}
+	}
+
+	fmt.Fprintln(out, rep.Summary)
+
+	return nil
+}
+
+func writeDiffHeader(out io.Writer, o compare.ObjectResult, color bool) {
+	header := fmt.Sprintf("# %s: %s", o.Status, o.ID())
+	if color {
+		header = ansiBold + header + ansiReset
+	}
+
+	fmt.Fprintln(out, header)
+
+	if o.Reference != nil {
+		fmt.Fprintf(out, "# reference: %s\n", o.Reference)
+	}
+
+	if o.Resource != nil {
+		fmt.Fprintf(out, "# resource: %s\n", o.Resource)
+	}
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9.-]`)
+
+// runDiffProgram writes both sides of the object to temporary files and returns the output of the
+// diff program run on them.
+func runDiffProgram(o compare.ObjectResult) ([]byte, error) {
+	from, err := k8sdiff.NewDiffVersion("REFERENCE")
+	if err != nil {
+		return nil, fmt.Errorf("could not create diff directory: %w", err)
+	}
+	defer func() { _ = from.Dir.Delete() }()
+
+	to, err := k8sdiff.NewDiffVersion("RESOURCE")
+	if err != nil {
+		return nil, fmt.Errorf("could not create diff directory: %w", err)
+	}
+	defer func() { _ = to.Dir.Delete() }()
+
+	name := unsafeFileChars.ReplaceAllString(strings.Join([]string{o.Kind, o.Namespace, o.Name}, "."), "_")
+
+	if err := from.Print(name, &unstructured.Unstructured{Object: o.ReferenceObject}, k8sdiff.Printer{}); err != nil {
+		return nil, fmt.Errorf("could not write reference of %s: %w", o.ID(), err)
+	}
+
+	if err := to.Print(name, &unstructured.Unstructured{Object: o.ResourceObject}, k8sdiff.Printer{}); err != nil {
+		return nil, fmt.Errorf("could not write resource of %s: %w", o.ID(), err)
+	}
+
+	diff := &bytes.Buffer{}
+	program := &k8sdiff.DiffProgram{
+		Exec:      exec.New(),
+		IOStreams: genericclioptions.IOStreams{In: os.Stdin, Out: diff, ErrOut: os.Stderr},
+	}
+
+	err = program.Run(filepath.Join(from.Dir.Name, name), filepath.Join(to.Dir.Name, name))
+
+	// diff exits with 1 when the files differ, which is the expected outcome here
+	var exitErr exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitStatus() == 1) {
+		return nil, fmt.Errorf("could not diff %s: %w", o.ID(), err)
+	}
+
+	return diff.Bytes(), nil
+}
+
+// colorizeDiff colors the lines of the diff by their prefix. Lines are read whole, however long
+// they are, as embedded certificates or large ConfigMap values often are.
+func colorizeDiff(diff []byte) ([]byte, error) {
+	colored := &bytes.Buffer{}
+	reader := bufio.NewReader(bytes.NewReader(diff))
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("could not read diff: %w", err)
+		}
+
+		if line != "" {
+			line = strings.TrimSuffix(line, "\n")
+
+			switch {
+			case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+				line = ansiBold + line + ansiReset
+			case strings.HasPrefix(line, "+"):
+				line = ansiGreen + line + ansiReset
+			case strings.HasPrefix(line, "-"):
+				line = ansiRed + line + ansiReset
+			case strings.HasPrefix(line, "@@"):
+				line = ansiCyan + line + ansiReset
+			}
+
+			colored.WriteString(line + "\n")
+		}
+
+		if err != nil {
+			return colored.Bytes(), nil
+		}
+	}
+}
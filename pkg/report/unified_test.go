@@ -0,0 +1,75 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/openshift-kni/reference-validator/pkg/compare"
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+)
+
+func Test_writeUnifiedDiff(t *testing.T) {
+	reference := newTestObject("v1", "ConfigMap", "ns1", "cm")
+	reference.Object["data"] = map[string]interface{}{"key": "reference"}
+	reference.Source = resource.Source{File: "reference.yaml"}
+	res := newTestObject("v1", "ConfigMap", "ns1", "cm")
+	res.Object["data"] = map[string]interface{}{"key": "resource", "extra": "allowed"}
+	res.SetLabels(map[string]string{"app": "allowed"})
+	res.Source = resource.Source{File: "resource.yaml"}
+
+	rep := compare.NewComparator(compare.Config{}).Compare([]resource.Object{reference}, []resource.Object{res})
+
+	tests := []struct {
+		name       string
+		color      bool
+		want       []string
+		wantNoneOf []string
+	}{
+		{
+			name:       "plain unified diff with a header per object",
+			want:       []string{"# different: v1/ConfigMap ns1/cm", "# reference: reference.yaml#0", "-  key: reference", "+  key: resource"},
+			wantNoneOf: []string{"extra: allowed", "app: allowed"},
+		},
+		{
+			name:  "colored unified diff",
+			color: true,
+			want:  []string{ansiRed + "-  key: reference" + ansiReset, ansiGreen + "+  key: resource" + ansiReset},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			if err := writeUnifiedDiff(out, rep, tt.color); err != nil {
+				t.Fatalf("writeUnifiedDiff() error = %v", err)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("writeUnifiedDiff() = %s, want it to contain %q", out.String(), want)
+				}
+			}
+
+			for _, unwanted := range tt.wantNoneOf {
+				if strings.Contains(out.String(), unwanted) {
+					t.Errorf("writeUnifiedDiff() = %s, want the fields absent from the reference left out", out.String())
+				}
+			}
+		})
+	}
+}
+
+func Test_colorizeDiff(t *testing.T) {
+	long := "+  ca.crt: " + strings.Repeat("a", 100*1024)
+
+	got, err := colorizeDiff([]byte("@@ -1 +1 @@\n" + long + "\n-  key: reference"))
+	if err != nil {
+		t.Fatalf("colorizeDiff() error = %v", err)
+	}
+
+	want := ansiCyan + "@@ -1 +1 @@" + ansiReset + "\n" + ansiGreen + long + ansiReset + "\n" + ansiRed + "-  key: reference" + ansiReset + "\n"
+	if string(got) != want {
+		t.Errorf("colorizeDiff() = %d bytes, want %d bytes with every line colored", len(got), len(want))
+	}
+}
@@ -0,0 +1,181 @@
+package resource
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Stdin is the path reading a YAML or JSON stream, such as the output of oc get -o yaml, from
+// the standard input.
+const Stdin = "-"
+
+// stdin is replaced in tests.
+var stdin io.Reader = os.Stdin
+
+var (
+	gzipSuffixes = []string{".tar.gz", ".tgz"}
+	tarSuffixes  = []string{".tar"}
+	zipSuffixes  = []string{".zip"}
+	// manifestSuffixes are the entries read from archives, which usually also hold logs.
+	manifestSuffixes = []string{".yaml", ".yml", ".json"}
+)
+
+// IsArchive is true for the tar, gzipped tar and zip files objects can be read from.
+func IsArchive(file string) bool {
+	fileInfo, err := os.Stat(file)
+	if err != nil || !fileInfo.Mode().IsRegular() {
+		return false
+	}
+
+	return hasSuffix(file, gzipSuffixes) || hasSuffix(file, tarSuffixes) || hasSuffix(file, zipSuffixes)
+}
+
+func hasSuffix(name string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(strings.ToLower(name), suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func readStdin() ([]Object, []Diagnostic) {
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return nil, []Diagnostic{{File: Stdin, Document: -1, Reason: err.Error()}}
+	}
+
+	return Decode(data, Stdin)
+}
+
+// WalkSource calls readEntry with every file of an archive or git source along with its content,
+// as it is read. The files are located by their path within the source, joined to it, like the
+// objects read from them.
+func WalkSource(source string, readEntry func(file string, r io.Reader)) error {
+	return walkStream(source, func(name string, r io.Reader) {
+		readEntry(filepath.Join(source, path.Clean("/"+name)), r)
+	})
+}
+
+// walkStream calls readEntry with every file of an archive or git source, as it is read.
+func walkStream(path string, readEntry func(name string, r io.Reader)) error {
+	switch {
+	case IsGitSource(path):
+		source, err := parseGitSource(path)
+		if err != nil {
+			return err
+		}
+
+		return source.walk(readEntry)
+	case hasSuffix(path, zipSuffixes):
+		return walkZip(path, readEntry)
+	default:
+		return walkTar(path, hasSuffix(path, gzipSuffixes), readEntry)
+	}
+}
+
+// readEntries decodes the YAML and JSON files of an archive or git source for which include is true,
+// without extracting them. The files are located by their path within the source, joined to it.
+func readEntries(source string, include func(name string) bool) ([]Object, []Diagnostic) {
+	var (
+		objects []Object
+		diags   []Diagnostic
+	)
+
+	readEntry := func(name string, r io.Reader) {
+		if !hasSuffix(name, manifestSuffixes) || !include(name) {
+			return
+		}
+
+		entry := filepath.Join(source, path.Clean("/"+name))
+
+		data, err := io.ReadAll(r)
+		if err != nil {
+			diags = append(diags, Diagnostic{File: entry, Document: -1, Reason: err.Error()})
+
+			return
+		}
+
+		entryObjects, entryDiags := Decode(data, entry)
+		objects = append(objects, entryObjects...)
+		diags = append(diags, entryDiags...)
+	}
+
+	if err := walkStream(source, readEntry); err != nil {
+		diags = append(diags, Diagnostic{File: source, Document: -1, Reason: err.Error()})
+	}
+
+	return objects, diags
+}
+
+func walkTar(file string, gzipped bool, readEntry func(name string, r io.Reader)) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("could not open archive: %w", err)
+	}
+	defer f.Close()
+
+	if !gzipped {
+		return readTar(f, readEntry)
+	}
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("invalid gzip archive: %w", err)
+	}
+	defer gz.Close()
+
+	return readTar(gz, readEntry)
+}
+
+func readTar(r io.Reader, readEntry func(name string, r io.Reader)) error {
+	tr := tar.NewReader(r)
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("invalid tar archive: %w", err)
+		}
+
+		if header.Typeflag == tar.TypeReg {
+			readEntry(header.Name, tr)
+		}
+	}
+}
+
+func walkZip(file string, readEntry func(name string, r io.Reader)) error {
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		return fmt.Errorf("invalid zip archive: %w", err)
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("could not read %s of zip archive: %w", f.Name, err)
+		}
+
+		readEntry(f.Name, rc)
+		rc.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package resource
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const (
+	testNamespace = `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: ns1
+`
+	testConfigMap = `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "cm1", "namespace": "ns1"}}`
+)
+
+var testEntries = map[string]string{
+	"must-gather/namespaces/ns1/ns1.yaml":    testNamespace,
+	"must-gather/namespaces/ns1/cm1.json":    testConfigMap,
+	"must-gather/namespaces/ns1/pod/log.txt": "not a manifest",
+}
+
+func mustWriteTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+func mustWriteTestTar(t *testing.T, name string, gzipped bool) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), name)
+
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var w io.Writer = f
+
+	if gzipped {
+		gz := gzip.NewWriter(f)
+		defer gz.Close()
+
+		w = gz
+	}
+
+	tw := tar.NewWriter(w)
+	defer tw.Close()
+
+	for name, content := range testEntries {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return file
+}
+
+func mustWriteTestZip(t *testing.T) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "artifacts.zip")
+
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	defer zw.Close()
+
+	for name, content := range testEntries {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return file
+}
+
+func TestReadDirs_streams(t *testing.T) {
+	type args struct {
+		path  string
+		stdin string
+	}
+
+	tests := []struct {
+		name      string
+		args      args
+		want      []string
+		wantDiags int
+	}{
+		{
+			name: "YAML and JSON entries of gzipped tar archives are decoded",
+			args: args{path: mustWriteTestTar(t, "must-gather.tar.gz", true)},
+			want: []string{"must-gather.tar.gz/must-gather/namespaces/ns1/cm1.json", "must-gather.tar.gz/must-gather/namespaces/ns1/ns1.yaml"},
+		},
+		{
+			name: "YAML and JSON entries of tar archives are decoded",
+			args: args{path: mustWriteTestTar(t, "must-gather.tar", false)},
+			want: []string{"must-gather.tar/must-gather/namespaces/ns1/cm1.json", "must-gather.tar/must-gather/namespaces/ns1/ns1.yaml"},
+		},
+		{
+			name: "YAML and JSON entries of zip archives are decoded",
+			args: args{path: mustWriteTestZip(t)},
+			want: []string{"artifacts.zip/must-gather/namespaces/ns1/cm1.json", "artifacts.zip/must-gather/namespaces/ns1/ns1.yaml"},
+		},
+		{
+			name: "the standard input is decoded",
+			args: args{path: Stdin, stdin: testNamespace + "---\n" + testConfigMap},
+			want: []string{"-", "-"},
+		},
+		{
+			name:      "invalid archives are reported",
+			args:      args{path: mustWriteTestFile(t, "invalid.tar.gz", "not an archive")},
+			wantDiags: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdin = strings.NewReader(tt.args.stdin)
+
+			got, diags := ReadDirs([]string{tt.args.path})
+			if len(diags) != tt.wantDiags {
+				t.Errorf("ReadDirs() diagnostics = %v, want %d", diags, tt.wantDiags)
+			}
+
+			var files []string
+			for _, o := range got {
+				files = append(files, strings.TrimPrefix(o.Source.File, filepath.Dir(tt.args.path)+string(filepath.Separator)))
+			}
+
+			sort.Strings(files)
+
+			if !reflect.DeepEqual(files, tt.want) {
+				t.Errorf("ReadDirs() = %v, want %v", files, tt.want)
+			}
+		})
+	}
+}
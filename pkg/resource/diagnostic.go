@@ -0,0 +1,73 @@
+package resource
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strconv"
+)
+
+// Diagnostic describes an input that could not be turned into a comparable object.
+type Diagnostic struct {
+	File string
+	// Document is the zero-based index of the YAML document within File, or -1 when the
+	// problem concerns the whole file.
+	Document int
+	// Line and Column locate the problem within File when known, and are 0 otherwise.
+	Line   int
+	Column int
+	Reason string
+}
+
+func (d Diagnostic) String() string {
+	location := d.File
+	if d.Line > 0 {
+		location += ":" + strconv.Itoa(d.Line)
+
+		if d.Column > 0 {
+			location += ":" + strconv.Itoa(d.Column)
+		}
+	}
+
+	if d.Document >= 0 {
+		location += fmt.Sprintf(" (document %d)", d.Document)
+	}
+
+	return fmt.Sprintf("%s: %s", location, d.Reason)
+}
+
+// NewDiagnostic reports a problem with the object read from src.
+func NewDiagnostic(src Source, reason string) Diagnostic {
+	return Diagnostic{File: src.File, Document: src.Document, Reason: reason}
+}
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+
+// lineFromYAMLError extracts the line number yaml parse errors embed in their message.
+func lineFromYAMLError(err error) int {
+	match := yamlErrorLine.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0
+	}
+
+	line, _ := strconv.Atoi(match[1])
+
+	return line
+}
+
+// LogDiagnostics logs every diagnostic as a warning.
+func LogDiagnostics(diags []Diagnostic) {
+	for _, d := range diags {
+		slog.Warn(d.String())
+	}
+}
+
+// PrintDiagnostics writes every diagnostic to out, one per line.
+func PrintDiagnostics(out io.Writer, diags []Diagnostic) {
+	fmt.Fprintf(out, "%d invalid input(s):\n", len(diags))
+
+	for _, d := range diags {
+		fmt.Fprintf(out, "  %s\n", d)
+	}
+}
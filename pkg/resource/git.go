@@ -0,0 +1,225 @@
+package resource
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/openshift-kni/reference-validator/pkg/util"
+)
+
+// GitPrefix starts the paths reading objects from a local git repository, in the form
+// git:<repository>@<ref>:<subdir>, where the subdirectory is optional.
+const GitPrefix = "git:"
+
+// gitSymlinkMode is the mode of the symbolic links of a tree.
+const gitSymlinkMode = "120000"
+
+var (
+	errInvalidGitSource = errors.New("invalid git source, must be git:<repository>@<ref>:<subdir>")
+	errInvalidGitObject = errors.New("invalid git object")
+)
+
+// gitSource is a directory of a commit of a local repository.
+type gitSource struct {
+	Repository string
+	Ref        string
+	Subdir     string
+}
+
+// IsGitSource is true for the paths starting with GitPrefix.
+func IsGitSource(path string) bool {
+	return strings.HasPrefix(path, GitPrefix)
+}
+
+// CheckGitSource returns an error when the path is not a git source whose repository is a
+// directory and whose ref, and subdirectory when set, resolve, so that mistyped sources are
+// reported before reading anything.
+func CheckGitSource(path string) error {
+	s, err := parseGitSource(path)
+	if err != nil {
+		return err
+	}
+
+	if !util.IsDirectory(s.Repository) {
+		return fmt.Errorf("%w: repository %s is not a directory", errInvalidGitSource, s.Repository)
+	}
+
+	stderr := &bytes.Buffer{}
+	//nolint:gosec // the repository and tree are passed as arguments, not through a shell
+	cmd := exec.Command("git", "-C", s.Repository, "rev-parse", "--verify", "--end-of-options", s.treeish())
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("could not resolve %s in %s: %w: %s", s.treeish(), s.Repository, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+// parseGitSource splits the path into its repository, ref and subdirectory. As repository paths
+// may hold an @, the repository is the longest prefix before an @ that is a directory.
+func parseGitSource(path string) (gitSource, error) {
+	source := strings.TrimPrefix(path, GitPrefix)
+
+	at := strings.Index(source, "@")
+	for i := strings.LastIndex(source, "@"); i > at; i = strings.LastIndex(source[:i], "@") {
+		if util.IsDirectory(source[:i]) {
+			at = i
+
+			break
+		}
+	}
+
+	if at <= 0 {
+		return gitSource{}, fmt.Errorf("%w: %s", errInvalidGitSource, path)
+	}
+
+	// ref names cannot hold a colon, so the first one starts the subdirectory
+	ref, subdir, _ := strings.Cut(source[at+1:], ":")
+	if ref == "" || strings.HasPrefix(ref, "-") {
+		return gitSource{}, fmt.Errorf("%w: %s", errInvalidGitSource, path)
+	}
+
+	return gitSource{Repository: source[:at], Ref: ref, Subdir: strings.Trim(subdir, "/")}, nil
+}
+
+// walk streams the files of the directory of the commit from the object database rather than a
+// checkout: git ls-tree lists the files of the tree and git cat-file reads them. Unlike git
+// archive, this ignores the export-ignore attributes. Symbolic links and submodules are skipped.
+func (s gitSource) walk(readEntry func(name string, r io.Reader)) error {
+	treeish := s.treeish()
+
+	files, err := s.listTree(treeish)
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		return nil
+	}
+
+	stderr := &bytes.Buffer{}
+	//nolint:gosec // the repository is passed as an argument, not through a shell
+	cmd := exec.Command("git", "-C", s.Repository, "cat-file", "--batch")
+	cmd.Stderr = stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("could not run git: %w", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("could not run git: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("could not run git: %w", err)
+	}
+
+	// the object names are written while the objects are read, so that neither side blocks
+	go func() {
+		defer stdin.Close()
+
+		for _, f := range files {
+			if _, err := fmt.Fprintln(stdin, f.object); err != nil {
+				return
+			}
+		}
+	}()
+
+	readErr := readBatch(bufio.NewReader(stdout), files, readEntry)
+	// drain what is left so that git does not block writing it
+	_, _ = io.Copy(io.Discard, stdout)
+
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("could not read %s of %s: %w: %s", treeish, s.Repository, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return readErr
+}
+
+// treeish names the directory of the commit, as <ref> or <ref>:<subdir>.
+func (s gitSource) treeish() string {
+	if s.Subdir == "" {
+		return s.Ref
+	}
+
+	return s.Ref + ":" + s.Subdir
+}
+
+// gitFile is a file of a tree along with the name of its blob.
+type gitFile struct {
+	name   string
+	object string
+}
+
+// listTree returns the regular files of the tree, recursively.
+func (s gitSource) listTree(treeish string) ([]gitFile, error) {
+	stderr := &bytes.Buffer{}
+	//nolint:gosec // the repository and tree are passed as arguments, not through a shell
+	cmd := exec.Command("git", "-C", s.Repository, "ls-tree", "-r", "-z", "--end-of-options", treeish)
+	cmd.Stderr = stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s of %s: %w: %s", treeish, s.Repository, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var files []gitFile
+
+	for _, entry := range strings.Split(string(out), "\x00") {
+		// <mode> SP <type> SP <object> TAB <file>
+		info, name, found := strings.Cut(entry, "\t")
+		fields := strings.Fields(info)
+
+		if !found || len(fields) != 3 || fields[1] != "blob" || fields[0] == gitSymlinkMode {
+			continue
+		}
+
+		files = append(files, gitFile{name: name, object: fields[2]})
+	}
+
+	return files, nil
+}
+
+// readBatch reads the output of git cat-file --batch for the files: a <object> SP <type> SP <size>
+// line followed by the content and a newline for every file.
+func readBatch(r *bufio.Reader, files []gitFile, readEntry func(name string, r io.Reader)) error {
+	for _, f := range files {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("could not read object %s: %w", f.object, err)
+		}
+
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			return fmt.Errorf("%w: %s", errInvalidGitObject, strings.TrimSpace(header))
+		}
+
+		size, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: %s", errInvalidGitObject, strings.TrimSpace(header))
+		}
+
+		content := io.LimitReader(r, size)
+		readEntry(f.name, content)
+
+		// skip what readEntry left and the trailing newline
+		if _, err := io.Copy(io.Discard, content); err != nil {
+			return fmt.Errorf("could not read object %s: %w", f.object, err)
+		}
+
+		if _, err := r.Discard(1); err != nil {
+			return fmt.Errorf("could not read object %s: %w", f.object, err)
+		}
+	}
+
+	return nil
+}
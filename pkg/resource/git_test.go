@@ -0,0 +1,165 @@
+package resource
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// mustWriteTestRepo commits the Namespace to a new repository, whose path holds an @, tagged v1,
+// and then replaces it with the ConfigMap, which it excludes from git archive.
+func mustWriteTestRepo(t *testing.T) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repo := filepath.Join(t.TempDir(), "user@host", "repo")
+	git := func(args ...string) {
+		t.Helper()
+
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+
+		file := filepath.Join(repo, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.MkdirAll(repo, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	git("init", "-q")
+	write("source-crs/ns1.yaml", testNamespace)
+	write("README.md", "not a manifest")
+	git("add", "-A")
+	git("commit", "-q", "-m", "v1")
+	git("tag", "v1")
+	git("rm", "-q", "source-crs/ns1.yaml")
+	write("source-crs/cm1.json", testConfigMap)
+	write(".gitattributes", "source-crs/cm1.json export-ignore\n")
+	git("add", "-A")
+	git("commit", "-q", "-m", "v2")
+
+	return repo
+}
+
+func TestReadDirs_git(t *testing.T) {
+	repo := mustWriteTestRepo(t)
+	output := filepath.Join(t.TempDir(), "output")
+
+	type args struct {
+		path string
+	}
+
+	tests := []struct {
+		name      string
+		args      args
+		want      []string
+		wantDiags int
+	}{
+		{
+			name: "the files of a directory are read at the given ref",
+			args: args{path: "git:" + repo + "@v1:source-crs"},
+			want: []string{"git:" + repo + "@v1:source-crs/ns1.yaml"},
+		},
+		{
+			name: "the files of the whole tree are read without subdirectory",
+			args: args{path: "git:" + repo + "@HEAD"},
+			want: []string{"git:" + repo + "@HEAD/source-crs/cm1.json"},
+		},
+		{
+			name:      "unknown refs are reported",
+			args:      args{path: "git:" + repo + "@v3:source-crs"},
+			wantDiags: 1,
+		},
+		{
+			name:      "refs that look like options are reported",
+			args:      args{path: "git:" + repo + "@--output=" + output},
+			wantDiags: 1,
+		},
+		{
+			name:      "sources without ref are reported",
+			args:      args{path: "git:" + repo},
+			wantDiags: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, diags := ReadDirs([]string{tt.args.path})
+			if len(diags) != tt.wantDiags {
+				t.Errorf("ReadDirs() diagnostics = %v, want %d", diags, tt.wantDiags)
+			}
+
+			var files []string
+			for _, o := range got {
+				files = append(files, o.Source.File)
+			}
+
+			if !reflect.DeepEqual(files, tt.want) {
+				t.Errorf("ReadDirs() = %v, want %v", files, tt.want)
+			}
+
+			if _, err := os.Stat(output); err == nil {
+				t.Errorf("ReadDirs() wrote %s", output)
+			}
+		})
+	}
+}
+
+func TestCheckGitSource(t *testing.T) {
+	repo := mustWriteTestRepo(t)
+
+	type args struct {
+		path string
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "sources whose ref and subdirectory resolve are valid",
+			args: args{path: "git:" + repo + "@v1:source-crs"},
+		},
+		{
+			name:    "repositories that are not directories are rejected",
+			args:    args{path: "git:" + filepath.Join(repo, "missing") + "@v1"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown refs are rejected",
+			args:    args{path: "git:" + repo + "@v3"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown subdirectories are rejected",
+			args:    args{path: "git:" + repo + "@v1:missing"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckGitSource(tt.args.path); (err != nil) != tt.wantErr {
+				t.Errorf("CheckGitSource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
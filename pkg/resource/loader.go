@@ -0,0 +1,191 @@
+// Package resource reads k8s objects from YAML and JSON files, keeping track of where each one
+// was read from.
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/openshift-kni/reference-validator/pkg/util"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+)
+
+// ReadDirs reads every file found under the given directories, the YAML and JSON entries of the
+// given archives and git sources, and the standard input for Stdin.
+func ReadDirs(dirs []string) ([]Object, []Diagnostic) {
+	var (
+		objects []Object
+		diags   []Diagnostic
+	)
+
+	for _, d := range dirs {
+		if d == Stdin || IsArchive(d) || IsGitSource(d) {
+			streamObjects, streamDiags := readStream(d)
+			objects = append(objects, streamObjects...)
+			diags = append(diags, streamDiags...)
+
+			continue
+		}
+
+		files, err := util.GetFileNames(d)
+		if err != nil {
+			diags = append(diags, Diagnostic{File: d, Document: -1, Reason: err.Error()})
+		}
+
+		for _, f := range files {
+			fileObjects, fileDiags := ReadFile(f)
+			objects = append(objects, fileObjects...)
+			diags = append(diags, fileDiags...)
+		}
+	}
+
+	return objects, diags
+}
+
+// readStream reads the objects of the standard input, or of the YAML and JSON files of an archive
+// or git source.
+func readStream(path string) ([]Object, []Diagnostic) {
+	if path == Stdin {
+		return readStdin()
+	}
+
+	return readEntries(path, func(string) bool { return true })
+}
+
+// ReadFile decodes every document of a YAML or JSON file, see Decode.
+func ReadFile(file string) ([]Object, []Diagnostic) {
+	yFile, err := os.ReadFile(file)
+	if err != nil {
+		return nil, []Diagnostic{{File: file, Document: -1, Reason: err.Error()}}
+	}
+
+	return Decode(yFile, file)
+}
+
+// Decode decodes every document of a YAML or JSON stream, skipping empty documents and
+// expanding List kinds into their items. Documents that cannot be decoded, or that do not
+// describe a k8s object, are returned as diagnostics. file is only used as provenance.
+func Decode(data []byte, file string) ([]Object, []Diagnostic) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+
+	var (
+		objects []Object
+		diags   []Diagnostic
+	)
+
+	for doc := 0; ; doc++ {
+		var node yaml.Node
+
+		err := decoder.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			// the decoder cannot resynchronize after a syntax error, so the rest of the file is lost
+			diags = append(diags, Diagnostic{
+				File: file, Document: doc, Line: lineFromYAMLError(err), Reason: fmt.Sprintf("invalid YAML: %v", err),
+			})
+
+			break
+		}
+
+		content, err := nodeToMap(&node)
+		if err != nil {
+			diags = append(diags, Diagnostic{
+				File: file, Document: doc, Line: node.Line, Column: node.Column,
+				Reason: fmt.Sprintf("not a k8s object: %v", err),
+			})
+
+			continue
+		}
+
+		if len(content) == 0 {
+			continue
+		}
+
+		src := Source{File: file, Document: doc}
+
+		expanded, err := expandList(unstructured.Unstructured{Object: content}, src)
+		if err != nil {
+			diags = append(diags, Diagnostic{
+				File: file, Document: doc, Line: node.Line, Column: node.Column, Reason: err.Error(),
+			})
+
+			continue
+		}
+
+		for _, o := range expanded {
+			if o.GetAPIVersion() == "" || o.GetKind() == "" {
+				diags = append(diags, Diagnostic{
+					File: file, Document: doc, Line: node.Line, Column: node.Column,
+					Reason: "object is missing apiVersion or kind",
+				})
+
+				continue
+			}
+
+			objects = append(objects, o)
+		}
+	}
+
+	return objects, diags
+}
+
+// nodeToMap converts a YAML document to the JSON-compatible map used by Unstructured, so that
+// numbers are decoded as int64 or float64 like objects coming from the API server.
+func nodeToMap(node *yaml.Node) (map[string]interface{}, error) {
+	var raw interface{}
+	if err := node.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	if raw == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	jsonBytes, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	content := map[string]interface{}{}
+	if err := utiljson.Unmarshal(jsonBytes, &content); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return content, nil
+}
+
+// expandList returns the items of a List kind, or the object itself for any other kind.
+func expandList(u unstructured.Unstructured, src Source) ([]Object, error) {
+	if !strings.HasSuffix(u.GetKind(), "List") || !u.IsList() {
+		return []Object{{Unstructured: u, Source: src}}, nil
+	}
+
+	var objects []Object
+
+	err := u.EachListItem(func(item runtime.Object) error {
+		itemU, ok := item.(*unstructured.Unstructured)
+		if !ok {
+			return errors.New("list item is not unstructured")
+		}
+
+		objects = append(objects, Object{Unstructured: *itemU, Source: src})
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not expand the items of %s: %w", u.GetKind(), err)
+	}
+
+	return objects, nil
+}
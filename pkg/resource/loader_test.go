@@ -0,0 +1,137 @@
+package resource
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestReadFile(t *testing.T) {
+	resourceNs := `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: cnfdf28
+  labels:
+    name: cnfdf28
+`
+	multiDocument := `
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: ns1
+---
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm1
+  namespace: ns1
+`
+	list := `
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ServiceAccount
+  metadata:
+    name: sa1
+- apiVersion: v1
+  kind: Secret
+  metadata:
+    name: secret1
+`
+	malformed := `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: ns1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+name: [cm1
+`
+	withoutKind := `
+apiVersion: v1
+metadata:
+  name: ns1
+`
+
+	type args struct {
+		file string
+	}
+
+	tests := []struct {
+		name      string
+		args      args
+		want      []string
+		wantDiags []Diagnostic
+	}{
+		{
+			name: "pass in a CR and get back Unstructured",
+			args: args{file: mustGetTestFilePath(t, resourceNs)},
+			want: []string{"Namespace"},
+		},
+		{
+			name: "every document is decoded and empty documents are skipped",
+			args: args{file: mustGetTestFilePath(t, multiDocument)},
+			want: []string{"Namespace", "ConfigMap"},
+		},
+		{
+			name: "List kinds are expanded into their items",
+			args: args{file: mustGetTestFilePath(t, list)},
+			want: []string{"ServiceAccount", "Secret"},
+		},
+		{
+			name:      "malformed documents are reported with their location",
+			args:      args{file: mustGetTestFilePath(t, malformed)},
+			want:      []string{"Namespace"},
+			wantDiags: []Diagnostic{{Document: 1, Line: 9}},
+		},
+		{
+			name:      "objects without kind are reported",
+			args:      args{file: mustGetTestFilePath(t, withoutKind)},
+			wantDiags: []Diagnostic{{Document: 0, Line: 2, Column: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotDiags := ReadFile(tt.args.file)
+
+			var kinds []string
+			for _, o := range got {
+				kinds = append(kinds, o.GetKind())
+			}
+
+			if !reflect.DeepEqual(kinds, tt.want) {
+				t.Errorf("ReadFile() = %v, want %v", kinds, tt.want)
+			}
+
+			if len(gotDiags) != len(tt.wantDiags) {
+				t.Fatalf("ReadFile() diagnostics = %v, want %v", gotDiags, tt.wantDiags)
+			}
+
+			for i, d := range gotDiags {
+				if d.Document != tt.wantDiags[i].Document || d.Line != tt.wantDiags[i].Line || d.Column != tt.wantDiags[i].Column {
+					t.Errorf("ReadFile() diagnostic = %v, want %v", d, tt.wantDiags[i])
+				}
+			}
+		})
+	}
+}
+
+func mustGetTestFilePath(t *testing.T, cr string) string {
+	t.Helper()
+	testDir := t.TempDir()
+	file, _ := os.CreateTemp(testDir, "testfile")
+
+	_, err := file.WriteString(cr)
+	if err != nil {
+		panic("could not create file")
+	}
+
+	return file.Name()
+}
@@ -0,0 +1,114 @@
+package resource
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/openshift-kni/reference-validator/pkg/util"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// Formats of the resource inputs.
+const (
+	// FormatFiles reads every file of the directories, and the YAML and JSON files of the archives,
+	// git sources and standard input.
+	FormatFiles = "files"
+	// FormatMustGather reads the objects of the namespaces and cluster-scoped-resources directories
+	// of an OpenShift must-gather.
+	FormatMustGather = "must-gather"
+)
+
+// Formats lists the supported resource formats.
+var Formats = []string{FormatFiles, FormatMustGather}
+
+const (
+	mustGatherNamespacesDir = "namespaces"
+	mustGatherClusterDir    = "cluster-scoped-resources"
+)
+
+// ReadMustGather reads the objects of must-gather directories, archives or git sources, where they
+// are stored as namespaces/<namespace>/<group>/<resource>.yaml lists and as
+// cluster-scoped-resources/<group>/<resource>/<name>.yaml objects, along with the logs and other
+// files that are left out. The image directory the must-gather nests them in can be part of the
+// path. An object collected more than once, such as a Pod also stored next to its logs, is only
+// returned the first time it is read.
+func ReadMustGather(paths []string) ([]Object, []Diagnostic) {
+	var (
+		objects []Object
+		diags   []Diagnostic
+	)
+
+	for _, p := range paths {
+		var (
+			pathObjects []Object
+			pathDiags   []Diagnostic
+		)
+
+		if util.IsDirectory(p) {
+			pathObjects, pathDiags = readMustGatherDir(p)
+		} else {
+			pathObjects, pathDiags = readEntries(p, isMustGatherFile)
+		}
+
+		objects = append(objects, pathObjects...)
+		diags = append(diags, pathDiags...)
+	}
+
+	return indexObjects(objects), diags
+}
+
+func readMustGatherDir(dir string) ([]Object, []Diagnostic) {
+	files, err := util.GetFileNames(dir)
+	if err != nil {
+		return nil, []Diagnostic{{File: dir, Document: -1, Reason: err.Error()}}
+	}
+
+	var (
+		objects []Object
+		diags   []Diagnostic
+	)
+
+	for _, f := range files {
+		rel, err := filepath.Rel(dir, f)
+		if err != nil || !hasSuffix(f, manifestSuffixes) || !isMustGatherFile(filepath.ToSlash(rel)) {
+			continue
+		}
+
+		fileObjects, fileDiags := ReadFile(f)
+		objects = append(objects, fileObjects...)
+		diags = append(diags, fileDiags...)
+	}
+
+	return objects, diags
+}
+
+// isMustGatherFile is true for the files under the namespaces or cluster-scoped-resources
+// directory of a must-gather, given their slash separated path.
+func isMustGatherFile(name string) bool {
+	for _, segment := range strings.Split(name, "/") {
+		if segment == mustGatherNamespacesDir || segment == mustGatherClusterDir {
+			return true
+		}
+	}
+
+	return false
+}
+
+// indexObjects returns the objects without the ones sharing the group, kind, namespace and name of
+// an object read before.
+func indexObjects(objects []Object) []Object {
+	index := make(map[object.ObjMetadata]bool, len(objects))
+	indexed := make([]Object, 0, len(objects))
+
+	for i := range objects {
+		id := object.UnstructuredToObjMetadata(&objects[i].Unstructured)
+		if index[id] {
+			continue
+		}
+
+		index[id] = true
+		indexed = append(indexed, objects[i])
+	}
+
+	return indexed
+}
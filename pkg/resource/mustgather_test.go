@@ -0,0 +1,121 @@
+package resource
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func mustWriteTestMustGather(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"image/timestamp": "2024-01-01",
+		"image/namespaces/ns1/ns1.yaml": `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: ns1
+`,
+		"image/namespaces/ns1/core/configmaps.yaml": `
+apiVersion: v1
+kind: ConfigMapList
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: cm1
+    namespace: ns1
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: cm2
+    namespace: ns1
+`,
+		"image/namespaces/ns1/core/pods.yaml": `
+apiVersion: v1
+kind: PodList
+items:
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: pod1
+    namespace: ns1
+`,
+		"image/namespaces/ns1/pods/pod1/pod1.yaml": `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: pod1
+  namespace: ns1
+`,
+		"image/namespaces/ns1/pods/pod1/app/app/logs/current.log": "not a manifest",
+		"image/cluster-scoped-resources/machineconfiguration.openshift.io/machineconfigs/mc1.yaml": `
+apiVersion: machineconfiguration.openshift.io/v1
+kind: MachineConfig
+metadata:
+  name: mc1
+`,
+		"image/event-filter.yaml": "not a must-gather object",
+	}
+
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestReadMustGather(t *testing.T) {
+	type args struct {
+		paths []string
+	}
+
+	tests := []struct {
+		name      string
+		args      args
+		want      []string
+		wantDiags int
+	}{
+		{
+			name: "lists are unpacked, objects are read once and other files are left out",
+			args: args{paths: []string{mustWriteTestMustGather(t)}},
+			want: []string{"ConfigMap ns1/cm1", "ConfigMap ns1/cm2", "MachineConfig /mc1", "Namespace /ns1", "Pod ns1/pod1"},
+		},
+		{
+			name: "only the must-gather files of archives are read",
+			args: args{paths: []string{mustWriteTestTar(t, "must-gather.tar.gz", true)}},
+			want: []string{"ConfigMap ns1/cm1", "Namespace /ns1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, diags := ReadMustGather(tt.args.paths)
+			if len(diags) != tt.wantDiags {
+				t.Errorf("ReadMustGather() diagnostics = %v, want %d", diags, tt.wantDiags)
+			}
+
+			var ids []string
+			for _, o := range got {
+				ids = append(ids, o.GetKind()+" "+o.GetNamespace()+"/"+o.GetName())
+			}
+
+			sort.Strings(ids)
+
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("ReadMustGather() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package resource
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	configurationPolicyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+)
+
+// Source is the location an object was read from.
+type Source struct {
+	File string `json:"file"`
+	// Document is the zero-based index of the YAML document within File.
+	Document int `json:"document"`
+}
+
+func (s Source) String() string {
+	return fmt.Sprintf("%s#%d", s.File, s.Document)
+}
+
+// Object is a k8s resource along with the location it was read from.
+type Object struct {
+	unstructured.Unstructured
+	Source Source
+	// ComplianceType and MetadataComplianceType are set on references extracted from the
+	// object-templates of a Policy, and are empty otherwise.
+	ComplianceType         configurationPolicyv1.ComplianceType
+	MetadataComplianceType configurationPolicyv1.MetadataComplianceType
+	// Policy, the namespace/name of a Policy, and Template, the name of its policy-template, are
+	// set on references extracted from a Policy, along with the Dependencies the template waits for
+	// and whether it leaves the Policy pending while waiting.
+	Policy        string
+	Template      string
+	Dependencies  []Dependency
+	IgnorePending bool
+	// NamespaceSelector is set on references extracted from a ConfigurationPolicy without a
+	// namespace, to be expanded into the namespaces the namespaceSelector of the policy selects.
+	NamespaceSelector *configurationPolicyv1.Target
+}
+
+// Dependency is a Policy, or the policy-template of a Policy, that must be in the Compliance state
+// before a template depending on it is applied.
+type Dependency struct {
+	Kind       string
+	Namespace  string
+	Name       string
+	Compliance string
+}
+
+func (d Dependency) String() string {
+	if d.Namespace == "" {
+		return fmt.Sprintf("%s %s", d.Kind, d.Name)
+	}
+
+	return fmt.Sprintf("%s %s/%s", d.Kind, d.Namespace, d.Name)
+}
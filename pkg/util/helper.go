@@ -32,5 +32,9 @@ func GetFileNames(dir string) ([]string, error) {
 		return nil
 	})
 
-	return files, fmt.Errorf("%w", err)
+	if err != nil {
+		return files, fmt.Errorf("%w", err)
+	}
+
+	return files, nil
 }
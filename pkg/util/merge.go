@@ -0,0 +1,110 @@
+package util
+
+import "k8s.io/apimachinery/pkg/runtime"
+
+// mergeKey is the field identifying the items of the lists merged by StrategicMerge, as most lists
+// of k8s CRs are keyed by name.
+const mergeKey = "name"
+
+// StrategicMerge applies an overlay to an object or field with strategic merge patch semantics,
+// without needing the schema of the object: maps are merged recursively and null values delete
+// fields, lists whose items all have a name are merged item by item, and other lists are replaced.
+// Neither argument is modified.
+func StrategicMerge(base, overlay interface{}) interface{} {
+	switch typedOverlay := overlay.(type) {
+	case map[string]interface{}:
+		baseMap, ok := base.(map[string]interface{})
+		if !ok {
+			return runtime.DeepCopyJSONValue(removeNulls(typedOverlay))
+		}
+
+		merged := runtime.DeepCopyJSON(baseMap)
+
+		for key, value := range typedOverlay {
+			if value == nil {
+				delete(merged, key)
+
+				continue
+			}
+
+			merged[key] = StrategicMerge(merged[key], value)
+		}
+
+		return merged
+	case []interface{}:
+		baseList, ok := base.([]interface{})
+		if !ok || !keyedByName(baseList) || !keyedByName(typedOverlay) {
+			return runtime.DeepCopyJSONValue(typedOverlay)
+		}
+
+		return mergeLists(baseList, typedOverlay)
+	}
+
+	return overlay
+}
+
+// mergeLists merges the items of the overlay into the base items with the same name, and appends
+// the others.
+func mergeLists(base, overlay []interface{}) []interface{} {
+	merged := make([]interface{}, 0, len(base)+len(overlay))
+	index := make(map[string]int, len(base))
+
+	for i, item := range base {
+		merged = append(merged, runtime.DeepCopyJSONValue(item))
+		index[nameOf(item)] = i
+	}
+
+	for _, item := range overlay {
+		name := nameOf(item)
+		if i, exists := index[name]; exists {
+			merged[i] = StrategicMerge(merged[i], item)
+
+			continue
+		}
+
+		index[name] = len(merged)
+		merged = append(merged, StrategicMerge(nil, item))
+	}
+
+	return merged
+}
+
+func keyedByName(items []interface{}) bool {
+	for _, item := range items {
+		if nameOf(item) == "" {
+			return false
+		}
+	}
+
+	return len(items) > 0
+}
+
+// nameOf returns the merge key of a list item, or an empty string if it has none.
+func nameOf(item interface{}) string {
+	itemMap, ok := item.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	name, _ := itemMap[mergeKey].(string)
+
+	return name
+}
+
+func removeNulls(obj map[string]interface{}) map[string]interface{} {
+	cleaned := make(map[string]interface{}, len(obj))
+
+	for key, value := range obj {
+		if value == nil {
+			continue
+		}
+
+		if valueMap, ok := value.(map[string]interface{}); ok {
+			value = removeNulls(valueMap)
+		}
+
+		cleaned[key] = value
+	}
+
+	return cleaned
+}
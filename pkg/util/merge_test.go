@@ -0,0 +1,62 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStrategicMerge(t *testing.T) {
+	type args struct {
+		base    interface{}
+		overlay interface{}
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want interface{}
+	}{
+		{
+			name: "maps are merged and null values delete fields",
+			args: args{
+				base:    map[string]interface{}{"a": "1", "b": map[string]interface{}{"c": "2", "d": "3"}},
+				overlay: map[string]interface{}{"b": map[string]interface{}{"c": "4", "d": nil}, "e": "5"},
+			},
+			want: map[string]interface{}{"a": "1", "b": map[string]interface{}{"c": "4"}, "e": "5"},
+		},
+		{
+			name: "named list items are merged by name",
+			args: args{
+				base: []interface{}{
+					map[string]interface{}{"name": "a", "value": "1"},
+					map[string]interface{}{"name": "b", "value": "2"},
+				},
+				overlay: []interface{}{
+					map[string]interface{}{"name": "b", "value": "3"},
+					map[string]interface{}{"name": "c", "value": "4"},
+				},
+			},
+			want: []interface{}{
+				map[string]interface{}{"name": "a", "value": "1"},
+				map[string]interface{}{"name": "b", "value": "3"},
+				map[string]interface{}{"name": "c", "value": "4"},
+			},
+		},
+		{
+			name: "other lists are replaced",
+			args: args{
+				base:    []interface{}{"a", "b"},
+				overlay: []interface{}{"c"},
+			},
+			want: []interface{}{"c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StrategicMerge(tt.args.base, tt.args.overlay); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StrategicMerge() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
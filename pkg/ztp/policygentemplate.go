@@ -0,0 +1,195 @@
+// Package ztp expands the ZTP PolicyGenTemplates of a GitOps repository into the CRs that the ZTP
+// kustomize plugin would wrap into Policies.
+package ztp
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+	"github.com/openshift-kni/reference-validator/pkg/util"
+	"k8s.io/apimachinery/pkg/runtime"
+	configurationPolicyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+)
+
+const (
+	// PolicyGenTemplateGroup is the API group of PolicyGenTemplates.
+	PolicyGenTemplateGroup = "ran.openshift.io"
+	policyGenTemplateKind  = "PolicyGenTemplate"
+
+	// sourceCRsDir is the directory next to a PolicyGenTemplate where the ZTP plugin looks up user
+	// provided source CRs.
+	sourceCRsDir = "source-crs"
+	// mcpPlaceholder is replaced in source CRs with the machine config pool of the PolicyGenTemplate.
+	mcpPlaceholder = "$mcp"
+)
+
+var errNoSourceFile = errors.New("could not find source CR")
+
+// policyGenTemplate holds the fields of a ran.openshift.io PolicyGenTemplate used to generate CRs.
+type policyGenTemplate struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		Mcp         string       `json:"mcp,omitempty"`
+		SourceFiles []sourceFile `json:"sourceFiles,omitempty"`
+	} `json:"spec"`
+}
+
+// sourceFile is a source CR along with the overlays applied to it.
+type sourceFile struct {
+	FileName       string                 `json:"fileName"`
+	PolicyName     string                 `json:"policyName,omitempty"`
+	ComplianceType string                 `json:"complianceType,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+	Spec           map[string]interface{} `json:"spec,omitempty"`
+	Data           map[string]interface{} `json:"data,omitempty"`
+	BinaryData     map[string]interface{} `json:"binaryData,omitempty"`
+}
+
+// ExpandPolicyGenTemplates replaces every PolicyGenTemplate with the CRs generated from its source
+// files, leaving the other objects untouched. Source files are looked up in the source-crs
+// directory next to the PolicyGenTemplate first, as the ZTP plugin does, and then in sourceCRDirs.
+// The objects read from a source-crs directory next to a PolicyGenTemplate are left out, since
+// they are only templates.
+func ExpandPolicyGenTemplates(objects []resource.Object, sourceCRDirs []string) ([]resource.Object, []resource.Diagnostic) {
+	var (
+		expanded []resource.Object
+		diags    []resource.Diagnostic
+	)
+
+	localSourceCRDirs := map[string]bool{}
+
+	for _, o := range objects {
+		if isPolicyGenTemplate(o) {
+			localSourceCRDirs[filepath.Join(filepath.Dir(o.Source.File), sourceCRsDir)] = true
+		}
+	}
+
+	for _, o := range objects {
+		if inDirs(o.Source.File, localSourceCRDirs) {
+			continue
+		}
+
+		if !isPolicyGenTemplate(o) {
+			expanded = append(expanded, o)
+
+			continue
+		}
+
+		pgt := policyGenTemplate{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.Object, &pgt); err != nil {
+			diags = append(diags, resource.NewDiagnostic(o.Source, fmt.Sprintf("invalid PolicyGenTemplate: %v", err)))
+
+			continue
+		}
+
+		dirs := append([]string{filepath.Join(filepath.Dir(o.Source.File), sourceCRsDir)}, sourceCRDirs...)
+
+		crs, pgtDiags := generate(pgt, o.Source, dirs)
+		expanded = append(expanded, crs...)
+		diags = append(diags, pgtDiags...)
+	}
+
+	return expanded, diags
+}
+
+func isPolicyGenTemplate(o resource.Object) bool {
+	gvk := o.GroupVersionKind()
+
+	return gvk.Group == PolicyGenTemplateGroup && gvk.Kind == policyGenTemplateKind
+}
+
+func inDirs(file string, dirs map[string]bool) bool {
+	for dir := filepath.Dir(file); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if dirs[dir] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// generate reads the source CRs of the PolicyGenTemplate read from src and applies its overlays.
+func generate(pgt policyGenTemplate, src resource.Source, dirs []string) ([]resource.Object, []resource.Diagnostic) {
+	slog.Info(fmt.Sprintf("generating %s --->", pgt.Metadata.Name))
+
+	var (
+		crs   []resource.Object
+		diags []resource.Diagnostic
+	)
+
+	for _, sf := range pgt.Spec.SourceFiles {
+		file, err := findSourceFile(sf.FileName, dirs)
+		if err != nil {
+			diags = append(diags, resource.NewDiagnostic(src, fmt.Sprintf("invalid source file of %s: %v", pgt.Metadata.Name, err)))
+
+			continue
+		}
+
+		sourceCRs, sourceDiags := resource.ReadFile(file)
+		diags = append(diags, sourceDiags...)
+
+		for _, cr := range sourceCRs {
+			if pgt.Spec.Mcp != "" {
+				replacePlaceholder(cr.Object, mcpPlaceholder, pgt.Spec.Mcp)
+			}
+
+			for field, overlay := range map[string]map[string]interface{}{
+				"metadata": sf.Metadata, "spec": sf.Spec, "data": sf.Data, "binaryData": sf.BinaryData,
+			} {
+				if overlay != nil {
+					cr.Object[field] = util.StrategicMerge(cr.Object[field], overlay)
+				}
+			}
+
+			slog.Info(fmt.Sprintf("found CR %s", cr.GetName()))
+
+			cr.Source = src
+			cr.ComplianceType = configurationPolicyv1.MustHave
+
+			if sf.ComplianceType != "" {
+				cr.ComplianceType = configurationPolicyv1.ComplianceType(sf.ComplianceType)
+			}
+
+			crs = append(crs, cr)
+		}
+	}
+
+	return crs, diags
+}
+
+func findSourceFile(name string, dirs []string) (string, error) {
+	for _, dir := range dirs {
+		file := filepath.Join(dir, name)
+		if fileInfo, err := os.Stat(file); err == nil && !fileInfo.IsDir() {
+			return file, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w %s in %s", errNoSourceFile, name, strings.Join(dirs, ", "))
+}
+
+// replacePlaceholder replaces placeholder with value in every string of the object, modifying maps
+// and lists in place.
+func replacePlaceholder(obj interface{}, placeholder, value string) interface{} {
+	switch typed := obj.(type) {
+	case map[string]interface{}:
+		for k, v := range typed {
+			typed[k] = replacePlaceholder(v, placeholder, value)
+		}
+	case []interface{}:
+		for i, v := range typed {
+			typed[i] = replacePlaceholder(v, placeholder, value)
+		}
+	case string:
+		return strings.ReplaceAll(typed, placeholder, value)
+	}
+
+	return obj
+}
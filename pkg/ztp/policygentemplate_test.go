@@ -0,0 +1,144 @@
+package ztp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/openshift-kni/reference-validator/pkg/resource"
+	configurationPolicyv1 "open-cluster-management.io/config-policy-controller/api/v1"
+)
+
+func mustWriteTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatalf("could not create test directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("could not write test file: %v", err)
+		}
+	}
+}
+
+func TestExpandPolicyGenTemplates(t *testing.T) {
+	sourceCRs := t.TempDir()
+	mustWriteTestFiles(t, sourceCRs, map[string]string{
+		"PtpConfigSlave.yaml": `
+apiVersion: ptp.openshift.io/v1
+kind: PtpConfig
+metadata:
+  name: slave
+  namespace: openshift-ptp
+spec:
+  profile:
+  - name: slave
+    interface: $interface
+    ptp4lOpts: -2 -s
+  recommend:
+  - profile: slave
+    priority: 4
+    match:
+    - nodeLabel: node-role.kubernetes.io/$mcp
+`,
+		"ClusterLogForwarder.yaml": `
+apiVersion: logging.openshift.io/v1
+kind: ClusterLogForwarder
+metadata:
+  name: instance
+  namespace: openshift-logging
+spec:
+  outputs: []
+`,
+	})
+
+	site := t.TempDir()
+	mustWriteTestFiles(t, site, map[string]string{
+		"group-du.yaml": `
+apiVersion: ran.openshift.io/v1
+kind: PolicyGenTemplate
+metadata:
+  name: group-du
+  namespace: ztp-group
+spec:
+  mcp: master
+  sourceFiles:
+  - fileName: PtpConfigSlave.yaml
+    policyName: config-policy
+    metadata:
+      name: du-ptp-slave
+    spec:
+      profile:
+      - name: slave
+        interface: ens5f0
+  - fileName: ClusterLogForwarder.yaml
+    policyName: log-policy
+    complianceType: mustonlyhave
+    spec:
+      outputs:
+      - type: kafka
+`,
+		"source-crs/ClusterLogForwarder.yaml": `
+apiVersion: logging.openshift.io/v1
+kind: ClusterLogForwarder
+metadata:
+  name: site-instance
+  namespace: openshift-logging
+`,
+		"namespace.yaml": "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: site\n",
+	})
+
+	objects, diags := resource.ReadDirs([]string{site})
+	if len(diags) > 0 {
+		t.Fatalf("ReadDirs() diagnostics = %v", diags)
+	}
+
+	got, diags := ExpandPolicyGenTemplates(objects, []string{sourceCRs})
+	if len(diags) > 0 {
+		t.Fatalf("ExpandPolicyGenTemplates() diagnostics = %v", diags)
+	}
+
+	byKind := map[string]resource.Object{}
+	for _, o := range got {
+		byKind[o.GetKind()] = o
+	}
+
+	if len(got) != 3 || len(byKind) != 3 {
+		t.Fatalf("ExpandPolicyGenTemplates() = %d objects, want a PtpConfig, a ClusterLogForwarder and a Namespace", len(got))
+	}
+
+	ptpConfig := byKind["PtpConfig"]
+	if ptpConfig.GetName() != "du-ptp-slave" || ptpConfig.GetNamespace() != "openshift-ptp" {
+		t.Errorf("PtpConfig metadata = %s/%s, want openshift-ptp/du-ptp-slave", ptpConfig.GetNamespace(), ptpConfig.GetName())
+	}
+
+	wantSpec := map[string]interface{}{
+		"profile": []interface{}{
+			map[string]interface{}{"name": "slave", "interface": "ens5f0", "ptp4lOpts": "-2 -s"},
+		},
+		"recommend": []interface{}{
+			map[string]interface{}{
+				"profile":  "slave",
+				"priority": int64(4),
+				"match":    []interface{}{map[string]interface{}{"nodeLabel": "node-role.kubernetes.io/master"}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(ptpConfig.Object["spec"], wantSpec) {
+		t.Errorf("PtpConfig spec = %v, want %v", ptpConfig.Object["spec"], wantSpec)
+	}
+
+	if ptpConfig.ComplianceType != configurationPolicyv1.MustHave || ptpConfig.Source.File != filepath.Join(site, "group-du.yaml") {
+		t.Errorf("PtpConfig compliance type = %s and source = %s", ptpConfig.ComplianceType, ptpConfig.Source)
+	}
+
+	// the source-crs directory next to the PolicyGenTemplate takes precedence
+	forwarder := byKind["ClusterLogForwarder"]
+	if forwarder.GetName() != "site-instance" || forwarder.ComplianceType != "mustonlyhave" {
+		t.Errorf("ClusterLogForwarder = %s %s, want site-instance mustonlyhave", forwarder.GetName(), forwarder.ComplianceType)
+	}
+}